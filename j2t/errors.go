@@ -0,0 +1,109 @@
+//------------------------------------------------------------------------------
+// the errors returned by the pipeline, typed so that the callers can decide
+// what to do with them, e.g. with errors.As
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"fmt"
+)
+
+// TypeConflictError : a property does not have the same type in all the JSON documents
+type TypeConflictError struct {
+	Path      string // the path of the property, e.g. "address/city"
+	File      string // the name of the JSON document where the conflicting type was found
+	Type      string // the type of the property in the common definition
+	FoundType string // the conflicting type found in the JSON document
+}
+
+func (thisErr *TypeConflictError) Error() string {
+	return fmt.Sprintf("type conflict for property '%s': its type is %s, but it is %s in file '%s'",
+		thisErr.Path, thisErr.Type, thisErr.FoundType, thisErr.File)
+}
+
+// OrderError : 2 properties do not appear in the same order in the JSON document and in the common definition
+type OrderError struct {
+	Path     string // the path of the property coming right after Previous in the JSON document, but before it in the common definition
+	Previous string // the path of the property coming right before Path in the JSON document
+	File     string // the name of the JSON document
+}
+
+func (thisErr *OrderError) Error() string {
+	return fmt.Sprintf("order violation in file '%s': '%s' comes before '%s', but the order is inversed in the common definition",
+		thisErr.File, thisErr.Previous, thisErr.Path)
+}
+
+// ConfigPathError : the config refers to a property that does not exist in the common definition
+type ConfigPathError struct {
+	Path   string // the unknown property path
+	File   string // the name of the config file
+	Column string // the configured column referring to this path
+}
+
+func (thisErr *ConfigPathError) Error() string {
+	return fmt.Sprintf("error in the configuration of column '%s' in '%s': this property does not seem to exist: '%s'!"+
+		" You might wanna watch for typos", thisErr.Column, thisErr.File, thisErr.Path)
+}
+
+// DuplicateKeyError : a JSON object has the same key twice
+type DuplicateKeyError struct {
+	Path string // the path of the duplicated key, e.g. "address/city"
+	File string // the name of the JSON document
+}
+
+func (thisErr *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate key '%s' in file '%s'", thisErr.Path, thisErr.File)
+}
+
+// RowsPathError : a JSON document has nothing at the path of its rows, i.e. no such property, or a null one
+type RowsPathError struct {
+	Path string // the configured rows path, e.g. "data/results"
+	Key  string // the key of the path that's missing, or null, e.g. "results"
+	File string // the name of the JSON document
+	Null bool   // true if the key is there, but with a null value
+}
+
+func (thisErr *RowsPathError) Error() string {
+	if thisErr.Null {
+		return fmt.Sprintf("could not find the rows at path '%s' in file '%s': '%s' is null", thisErr.Path, thisErr.File, thisErr.Key)
+	}
+	return fmt.Sprintf("could not find the rows at path '%s' in file '%s': there's no '%s' property", thisErr.Path, thisErr.File, thisErr.Key)
+}
+
+// WriteError : a value, or a cell, could not be written out
+type WriteError struct {
+	Path string // the path of the property being written, if any
+	File string // the name of the JSON document being written, if any
+	Cell string // the cell being written, e.g. "B12", if any
+	Err  error  // the cause
+}
+
+func (thisErr *WriteError) Error() string {
+	msg := "could not write"
+	if thisErr.Path != "" {
+		msg += fmt.Sprintf(" property '%s'", thisErr.Path)
+	}
+	if thisErr.File != "" {
+		msg += fmt.Sprintf(" for file '%s'", thisErr.File)
+	}
+	if thisErr.Cell != "" {
+		msg += fmt.Sprintf(" at cell %s", thisErr.Cell)
+	}
+	return fmt.Sprintf("%s. Cause: %s", msg, thisErr.Err)
+}
+
+func (thisErr *WriteError) Unwrap() error {
+	return thisErr.Err
+}
+
+// the conflict between the kind of this property in the common definition, and a value found in the given JSON map
+func (thisProperty *chainedProperty) conflictWith(jsonMap *fileMap, value interface{}) error {
+	foundType := valueKind(value)
+	return &TypeConflictError{
+		Path:      string(thisProperty.getPath()),
+		File:      jsonMap.root().name,
+		Type:      thisProperty.kind.String(),
+		FoundType: foundType.String(),
+	}
+}
@@ -0,0 +1,100 @@
+//------------------------------------------------------------------------------
+// testing that the pipeline stops with typed errors, which the callers can
+// check with errors.As, along with the property path & file name they carry
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTypedErrors(t *testing.T) {
+
+	cases := []struct {
+		name    string
+		files   fstest.MapFS
+		options Options
+		want    error // the error expected in the chain, with all its fields
+	}{
+		{
+			name: "type conflict",
+			files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"id": 1, "size": 12}`)},
+				"b.json": {Data: []byte(`{"id": 2, "size": "big"}`)},
+			},
+			want: &TypeConflictError{Path: "size", File: "b", Type: "float64", FoundType: "string"},
+		},
+		{
+			name: "nested type conflict",
+			files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"id": 1, "address": {"zip": "75001"}}`)},
+				"b.json": {Data: []byte(`{"id": 2, "address": {"zip": 69001}}`)},
+			},
+			want: &TypeConflictError{Path: "address/zip", File: "b", Type: "string", FoundType: "float64"},
+		},
+		{
+			name: "duplicate key",
+			files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"id": 1, "address": {"city": "Paris", "city": "Lyon"}}`)},
+			},
+			want: &DuplicateKeyError{Path: "address/city", File: "a"},
+		},
+		{
+			name: "missing rows",
+			files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"data": {"results": [{"id": 1}]}}`)},
+				"b.json": {Data: []byte(`{"data": {"total": 0}}`)},
+			},
+			options: Options{RowsPath: "data/results"},
+			want:    &RowsPathError{Path: "data/results", Key: "results", File: "b"},
+		},
+		{
+			name: "null rows",
+			files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"data": null}`)},
+			},
+			options: Options{RowsPath: "data/results"},
+			want:    &RowsPathError{Path: "data/results", Key: "data", File: "a", Null: true},
+		},
+		{
+			name: "order violation",
+			files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"id": 1, "name": "alpha"}`)},
+				"b.json": {Data: []byte(`{"name": "beta", "id": 2}`)},
+			},
+			options: Options{Order: "strict"},
+			want:    &OrderError{Path: "id", Previous: "name", File: "b"},
+		},
+		{
+			name: "unknown config path",
+			files: fstest.MapFS{
+				"a.json":    {Data: []byte(`{"id": 1, "price": 10}`)},
+				"test.json": {Data: []byte(`{"NewColumns": [{"Name": "total", "PutAfter": "prise", "Formula": "{price}*2"}]}`)},
+			},
+			want: &ConfigPathError{Path: "prise", File: "test.json", Column: "total"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			errRun := NewTable("test", testCase.options).Run(testCase.files, memoryOpener(map[string]*memoryFile{}))
+			if errRun == nil {
+				t.Fatalf("expected a %T, got no error", testCase.want)
+			}
+
+			// a pointer to a nil error of the expected type, for errors.As to fill
+			target := reflect.New(reflect.TypeOf(testCase.want))
+			if !errors.As(errRun, target.Interface()) {
+				t.Fatalf("expected a %T, got: %v", testCase.want, errRun)
+			}
+			if got := target.Elem().Interface(); !reflect.DeepEqual(got, testCase.want) {
+				t.Errorf("expected %#v, got %#v", testCase.want, got)
+			}
+		})
+	}
+}
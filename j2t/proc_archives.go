@@ -0,0 +1,94 @@
+//------------------------------------------------------------------------------
+// the code here is about reading the JSON documents from compressed files,
+// and from archives, without having to unpack them first
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// scanning a gzipped JSON file, e.g. "export.json.gz", as if it was "export.json"
+func scanGz(fileName string, reader io.Reader, rowsPath path, collect func(documentPath string, fileMaps []*fileMap)) error {
+
+	gzReader, errGz := gzip.NewReader(reader)
+	if errGz != nil {
+		return fmt.Errorf("error while uncompressing '%s'. Cause: %s", fileName, errGz)
+	}
+	defer gzReader.Close()
+
+	return scanDocuments(strings.TrimSuffix(fileName, ".gz"), gzReader, rowsPath, collect)
+}
+
+// scanning the JSON members of a zip archive, each one being seen as a file within the archive seen as a folder
+func scanZip(fileName string, reader io.Reader, rowsPath path, collect func(documentPath string, fileMaps []*fileMap)) error {
+
+	// the zip format needs random access
+	zipBytes, errRead := ioutil.ReadAll(reader)
+	if errRead != nil {
+		return fmt.Errorf("error while reading '%s'. Cause: %s", fileName, errRead)
+	}
+	zipReader, errZip := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if errZip != nil {
+		return fmt.Errorf("error while opening the archive '%s'. Cause: %s", fileName, errZip)
+	}
+
+	// following the members' names, to make sure the treatment is always the same
+	members := append([]*zip.File{}, zipReader.File...)
+	sort.Slice(members, func(i int, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+
+	for _, member := range members {
+		if member.FileInfo().IsDir() || !isJSON(member.Name) {
+			continue
+		}
+		memberReader, errOpen := member.Open()
+		if errOpen != nil {
+			return fmt.Errorf("error while opening '%s' in '%s'. Cause: %s", member.Name, fileName, errOpen)
+		}
+		errScan := scanDocuments(fileName+"/"+member.Name, memberReader, rowsPath, collect)
+		memberReader.Close()
+		if errScan != nil {
+			return errScan
+		}
+	}
+
+	return nil
+}
+
+// scanning the JSON members of a gzipped tar archive, each one being seen as a file within the archive seen as a folder
+func scanTarGz(fileName string, reader io.Reader, rowsPath path, collect func(documentPath string, fileMaps []*fileMap)) error {
+
+	gzReader, errGz := gzip.NewReader(reader)
+	if errGz != nil {
+		return fmt.Errorf("error while uncompressing '%s'. Cause: %s", fileName, errGz)
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, errNext := tarReader.Next()
+		if errNext == io.EOF {
+			return nil
+		}
+		if errNext != nil {
+			return fmt.Errorf("error while reading the archive '%s'. Cause: %s", fileName, errNext)
+		}
+		if header.Typeflag != tar.TypeReg || !isJSON(header.Name) {
+			continue
+		}
+		if errScan := scanDocuments(fileName+"/"+strings.TrimPrefix(header.Name, "./"), tarReader, rowsPath, collect); errScan != nil {
+			return errScan
+		}
+	}
+}
@@ -0,0 +1,335 @@
+//------------------------------------------------------------------------------
+// handling the arrays found in each JSON map, by turning them into values
+// or submaps, depending on the configured strategy for each array path
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultArraySeparator = ", "      // the default separator used to join the items of an array
+	childParentProperty   = "_parent" // the property referencing the parent row, in a child sheet
+	childIndexProperty    = "_index"  // the property holding the item's index, in a child sheet
+	maxSheetNameLength    = 31        // Excel does not accept longer sheet names
+)
+
+// what's needed to handle the arrays of all the JSON maps
+type arrayContext struct {
+	config       *j2tConfig
+	objectArrays map[path]bool          // the arrays containing objects, indexed by their config path
+	childMaps    map[path][]*fileMap    // the items of the arrays exploded into child sheets, indexed by their config path
+	strategies   map[path]arrayStrategy // the strategy used for each array, indexed by its config path
+}
+
+// handling the arrays within each original json file; the items of the arrays exploded into child sheets
+// are returned, as new root maps, indexed by the array path - along with the strategy used for each array
+func handleArrays(config *j2tConfig, jsonMaps []*fileMap) (map[path][]*fileMap, map[path]arrayStrategy, error) {
+
+	context := &arrayContext{
+		config:       config,
+		objectArrays: map[path]bool{},
+		childMaps:    map[path][]*fileMap{},
+		strategies:   map[path]arrayStrategy{},
+	}
+
+	// the default strategy depends on the array content, so it has to be the same for all the files
+	for _, jsonMap := range jsonMaps {
+		jsonMap.findObjectArrays("", context.objectArrays)
+	}
+
+	for _, jsonMap := range jsonMaps {
+		if errHandle := jsonMap.handleArrays(context, ""); errHandle != nil {
+			return nil, nil, fmt.Errorf("error in file '%s': %w", jsonMap.name, errHandle)
+		}
+	}
+
+	return context.childMaps, context.strategies, nil
+}
+
+// recursively looking for the arrays containing objects, indexed by their config path
+func (thisMap *fileMap) findObjectArrays(configPath path, objectArrays map[path]bool) {
+
+	for propertyName, subMap := range thisMap.subMaps {
+		subMap.findObjectArrays(configPath+path(propertyName)+"/", objectArrays)
+	}
+
+	for propertyName, itemMaps := range thisMap.arrayItems {
+		for _, itemMap := range itemMaps {
+			if itemMap != nil {
+				objectArrays[configPath+path(propertyName)] = true
+				itemMap.findObjectArrays(configPath+path(propertyName)+"/", objectArrays)
+			}
+		}
+	}
+}
+
+// recursively handling the arrays of a map; the config path is the path of this map,
+// without the array indexes, so that all the items of an array share the same config
+func (thisMap *fileMap) handleArrays(context *arrayContext, configPath path) error {
+
+	// copying the properties, since they might change along the way
+	propertyNames := append([]string{}, thisMap.orderedProperties...)
+
+	for _, propertyName := range propertyNames {
+
+		propertyPath := configPath + path(propertyName)
+
+		if subMap := thisMap.subMaps[propertyName]; subMap != nil {
+
+			// going deeper
+			if errHandle := subMap.handleArrays(context, propertyPath+"/"); errHandle != nil {
+				return errHandle
+			}
+
+		} else if items, isArray := thisMap.values[propertyName].([]interface{}); isArray {
+
+			// handling this array
+			if errHandle := thisMap.handleArray(context, propertyName, propertyPath, items); errHandle != nil {
+				return errHandle
+			}
+		}
+	}
+
+	return nil
+}
+
+// handling 1 array, according to the strategy configured for its path
+func (thisMap *fileMap) handleArray(context *arrayContext, propertyName string, propertyPath path, items []interface{}) error {
+
+	arrayConf, errConf := context.config.getArrayConfig(propertyPath, context.objectArrays[propertyPath])
+	if errConf != nil {
+		return errConf
+	}
+	context.strategies[propertyPath] = arrayConf.Strategy
+
+	switch arrayConf.Strategy {
+
+	case arrayStrategyCOUNT:
+		thisMap.values[propertyName] = json.Number(strconv.Itoa(len(items)))
+
+	case arrayStrategyJOIN:
+		separator := arrayConf.Separator
+		if separator == "" {
+			separator = defaultArraySeparator
+		}
+		itemStrings := make([]string, len(items))
+		for i, item := range items {
+			itemString, errString := itemToString(item, thisMap.arrayItems[propertyName][i])
+			if errString != nil {
+				return fmt.Errorf("could not join the items of array '%s': %s", propertyPath, errString)
+			}
+			itemStrings[i] = itemString
+		}
+		thisMap.values[propertyName] = strings.Join(itemStrings, separator)
+
+	case arrayStrategyCOLUMNS:
+
+		// an empty array brings no column at all
+		if len(items) == 0 {
+			thisMap.removeProperty(propertyName)
+			return nil
+		}
+
+		// the array becomes a submap, with 1 property per index
+		arrayMap := &fileMap{
+			parent:     thisMap,
+			name:       propertyName,
+			subMaps:    map[string]*fileMap{},
+			values:     map[string]interface{}{},
+			arrayItems: map[string][]*fileMap{},
+		}
+
+		for i, item := range items {
+
+			index := strconv.Itoa(i)
+			arrayMap.orderedProperties = append(arrayMap.orderedProperties, index)
+
+			if itemMap := thisMap.arrayItems[propertyName][i]; itemMap != nil {
+
+				// an object, which becomes a sub-submap, with its own arrays to handle
+				itemMap.parent = arrayMap
+				itemMap.name = index
+				arrayMap.subMaps[index] = itemMap
+				if errHandle := itemMap.handleArrays(context, propertyPath+"/"); errHandle != nil {
+					return errHandle
+				}
+
+			} else {
+
+				// a simple value; arrays within arrays are kept as JSON strings
+				itemString, errString := itemToString(item, nil)
+				if errString != nil {
+					return fmt.Errorf("could not handle the items of array '%s': %s", propertyPath, errString)
+				}
+				if _, isArray := item.([]interface{}); isArray {
+					arrayMap.values[index] = itemString
+				} else {
+					arrayMap.values[index] = item
+				}
+			}
+		}
+
+		// replacing the array with the new submap
+		delete(thisMap.values, propertyName)
+		delete(thisMap.arrayItems, propertyName)
+		thisMap.subMaps[propertyName] = arrayMap.chain()
+
+	case arrayStrategySHEET:
+
+		// the parent row only keeps the number of items
+		thisMap.values[propertyName] = json.Number(strconv.Itoa(len(items)))
+
+		// each object becomes a row of its own, referencing the parent row; the other items are ignored
+		parentName := thisMap.root().name
+		for i, itemMap := range thisMap.arrayItems[propertyName] {
+			if itemMap != nil {
+				if errChild := itemMap.becomeChildRow(parentName, i); errChild != nil {
+					return fmt.Errorf("could not explode the items of array '%s': %w", propertyPath, errChild)
+				}
+				if errHandle := itemMap.handleArrays(context, propertyPath+"/"); errHandle != nil {
+					return errHandle
+				}
+				context.childMaps[propertyPath] = append(context.childMaps[propertyPath], itemMap)
+			}
+		}
+		delete(thisMap.arrayItems, propertyName)
+	}
+
+	return nil
+}
+
+// getting the config for the array at the given path; by default, arrays of objects are
+// spread over several columns, whereas the other arrays are joined
+func (config *j2tConfig) getArrayConfig(arrayPath path, containsObjects bool) (*arrayConfig, error) {
+
+	for _, arrayConf := range config.Arrays {
+		if arrayConf.Path == arrayPath {
+			switch arrayConf.Strategy {
+			case arrayStrategyJOIN, arrayStrategyCOLUMNS, arrayStrategyCOUNT, arrayStrategySHEET:
+				return arrayConf, nil
+			}
+			return nil, fmt.Errorf("unknown strategy '%s' configured for array '%s'", arrayConf.Strategy, arrayPath)
+		}
+	}
+
+	if containsObjects {
+		return &arrayConfig{Path: arrayPath, Strategy: arrayStrategyCOLUMNS}, nil
+	}
+
+	return &arrayConfig{Path: arrayPath, Strategy: arrayStrategyJOIN}, nil
+}
+
+// turning an array item into a root map, i.e. a row of a child sheet, with extra properties
+// referencing the parent row, and the item's index within its array - which the item cannot have already
+func (itemMap *fileMap) becomeChildRow(parentName string, index int) error {
+
+	for _, propertyName := range itemMap.orderedProperties {
+		if propertyName == childParentProperty || propertyName == childIndexProperty {
+			return fmt.Errorf("item %d has a '%s' property, which is reserved to reference the parent row", index, propertyName)
+		}
+	}
+
+	itemMap.parent = nil
+	itemMap.name = fmt.Sprintf("%s#%d", parentName, index)
+
+	itemMap.orderedProperties = append([]string{childParentProperty, childIndexProperty}, itemMap.orderedProperties...)
+	itemMap.values[childParentProperty] = parentName
+	itemMap.values[childIndexProperty] = json.Number(strconv.Itoa(index))
+
+	itemMap.chain()
+
+	return nil
+}
+
+// merging the rows of each child sheet into its own common definition
+func (commonDef *fileMap) addChildSheets(config *j2tConfig, childMaps map[path][]*fileMap, settings *mergeSettings) error {
+
+	// sorting the paths, to make sure the sheets are always written in the same order
+	arrayPaths := []string{}
+	for arrayPath := range childMaps {
+		arrayPaths = append(arrayPaths, string(arrayPath))
+	}
+	sort.Strings(arrayPaths)
+
+	// the seed, if any, is for the main definition only
+	childSettings := *settings
+	childSettings.seed = nil
+
+	// the sheets' names have to be unique, whatever the case
+	usedNames := map[string]bool{strings.ToLower(mainSheetName): true, strings.ToLower(statsSheetName): true}
+
+	for _, arrayPath := range arrayPaths {
+
+		childDef, errMerge := merge(childMaps[path(arrayPath)], config, path(arrayPath)+"/", &childSettings)
+		if errMerge != nil {
+			return fmt.Errorf("error while merging the items of array '%s': %w", arrayPath, errMerge)
+		}
+		childDef.sheetName = getChildSheetName(path(arrayPath), usedNames)
+		childDef.arrayPath = path(arrayPath)
+		if errConf := childDef.initConfigMap(config); errConf != nil {
+			return errConf
+		}
+
+		commonDef.childSheets = append(commonDef.childSheets, &childSheet{
+			path:      path(arrayPath),
+			commonDef: childDef,
+			jsonMaps:  childMaps[path(arrayPath)],
+		})
+	}
+
+	return nil
+}
+
+// the name of the sheet for a given array path, with respect to Excel's constraints on sheet names: no special
+// characters, at most 31 characters - the end of the path being kept - and unique, whatever the case, among the given
+// names, to which it's added; a name already used gets a suffix, e.g. "items~2"
+func getChildSheetName(arrayPath path, usedNames map[string]bool) string {
+
+	sheetName := strings.NewReplacer("/", ".", "\\", ".", "?", "", "*", "", "[", "(", "]", ")", ":", "").Replace(string(arrayPath))
+
+	uniqueName := keepEnd(sheetName, maxSheetNameLength)
+	for i := 2; usedNames[strings.ToLower(uniqueName)]; i++ {
+		suffix := fmt.Sprintf("~%d", i)
+		uniqueName = keepEnd(sheetName, maxSheetNameLength-len(suffix)) + suffix
+	}
+	usedNames[strings.ToLower(uniqueName)] = true
+
+	return uniqueName
+}
+
+// the end of the given text, up to the given number of characters
+func keepEnd(text string, maxLength int) string {
+	if runes := []rune(text); len(runes) > maxLength {
+		return string(runes[len(runes)-maxLength:])
+	}
+	return text
+}
+
+// the string representation of an array item; objects and arrays are represented as JSON
+func itemToString(item interface{}, itemMap *fileMap) (string, error) {
+
+	// an object, already built, so we keep the properties' order
+	if itemMap != nil {
+		itemBytes, errMarshal := itemMap.MarshalJSON()
+		return string(itemBytes), errMarshal
+	}
+
+	switch value := item.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case []interface{}, map[string]interface{}:
+		itemBytes, errMarshal := json.Marshal(value)
+		return string(itemBytes), errMarshal
+	}
+
+	return fmt.Sprintf("%v", item), nil
+}
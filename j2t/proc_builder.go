@@ -0,0 +1,165 @@
+//------------------------------------------------------------------------------
+// the code here is about how we build a file map from a JSON file content
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// the order of the keys of a JSON object, and of the objects it contains, as read from the JSON content
+type keyOrder struct {
+	keys    []string               // the object's keys, in their order of appearance
+	objects map[string]*keyOrder   // the order within the object values
+	items   map[string][]*keyOrder // the order within the object items of the array values; nil for the other items
+}
+
+// reading the next JSON value from the decoder's tokens, giving: the value, as json.Unmarshal would; the order of its keys,
+// if it's an object; the order of its items' keys, if it's an array. The given path is the one of the value, for the errors
+func readValue(decoder *json.Decoder, valuePath string) (interface{}, *keyOrder, []*keyOrder, error) {
+
+	token, errToken := decoder.Token()
+	if errToken != nil {
+		return nil, nil, nil, errToken
+	}
+
+	switch token {
+
+	// an object: keeping track of its keys' order
+	case json.Delim('{'):
+		content := map[string]interface{}{}
+		order := &keyOrder{objects: map[string]*keyOrder{}, items: map[string][]*keyOrder{}}
+		for decoder.More() {
+			keyToken, errKey := decoder.Token()
+			if errKey != nil {
+				return nil, nil, nil, errKey
+			}
+			key := keyToken.(string)
+			if _, duplicate := content[key]; duplicate {
+				return nil, nil, nil, &DuplicateKeyError{Path: valuePath + key}
+			}
+			value, valueOrder, itemOrders, errValue := readValue(decoder, valuePath+key+"/")
+			if errValue != nil {
+				return nil, nil, nil, errValue
+			}
+			content[key] = value
+			order.keys = append(order.keys, key)
+			if valueOrder != nil {
+				order.objects[key] = valueOrder
+			}
+			if itemOrders != nil {
+				order.items[key] = itemOrders
+			}
+		}
+		if _, errEnd := decoder.Token(); errEnd != nil {
+			return nil, nil, nil, errEnd
+		}
+		return content, order, nil, nil
+
+	// an array: keeping track of the keys' order for each item
+	case json.Delim('['):
+		items := []interface{}{}
+		itemOrders := []*keyOrder{}
+		for i := 0; decoder.More(); i++ {
+			item, itemOrder, _, errItem := readValue(decoder, fmt.Sprintf("%s%d/", valuePath, i))
+			if errItem != nil {
+				return nil, nil, nil, errItem
+			}
+			items = append(items, item)
+			itemOrders = append(itemOrders, itemOrder)
+		}
+		if _, errEnd := decoder.Token(); errEnd != nil {
+			return nil, nil, nil, errEnd
+		}
+		return items, nil, itemOrders, nil
+	}
+
+	// a simple value
+	return token, nil, nil, nil
+}
+
+// recursively building an ordered map, with the given order for its keys
+func (thisMap *fileMap) build(order *keyOrder) *fileMap {
+
+	// some initialisation
+	thisMap.subMaps = map[string]*fileMap{}
+	thisMap.values = map[string]interface{}{}
+	thisMap.arrayItems = map[string][]*fileMap{}
+
+	// going through the content of THIS map, in the original order
+	for _, propertyName := range order.keys {
+
+		// adding the proerty to the others
+		thisMap.orderedProperties = append(thisMap.orderedProperties, propertyName)
+
+		// dealing with the value; a null value is kept as a nil simple value
+		value := thisMap.originalContent[propertyName]
+		if content, isObject := value.(map[string]interface{}); isObject {
+
+			// adding a new, built, sub-map
+			thisMap.subMaps[propertyName] = (&fileMap{
+				parent:          thisMap,
+				name:            propertyName,
+				originalContent: content,
+			}).build(order.objects[propertyName])
+
+		} else if items, isArray := value.([]interface{}); isArray {
+
+			// adding an array, while building the objects it may contain
+			thisMap.values[propertyName] = items
+			thisMap.arrayItems[propertyName] = thisMap.buildItems(items, order.items[propertyName])
+
+		} else {
+
+			// adding a simple value
+			thisMap.values[propertyName] = value
+		}
+	}
+
+	// building the chained properties, and returning this map
+	return thisMap.chain()
+}
+
+// building the maps for the objects contained in an array; the non-object items are left as nil
+func (thisMap *fileMap) buildItems(items []interface{}, itemOrders []*keyOrder) []*fileMap {
+
+	builtItems := make([]*fileMap, len(items))
+
+	for i, item := range items {
+		if content, isObject := item.(map[string]interface{}); isObject {
+			builtItems[i] = (&fileMap{
+				parent:          thisMap,
+				name:            strconv.Itoa(i),
+				originalContent: content,
+			}).build(itemOrders[i])
+		}
+	}
+
+	return builtItems
+}
+
+// (re)building the chained properties from the ordered properties
+func (thisMap *fileMap) chain() *fileMap {
+
+	thisMap.chainedProperties = map[string]*chainedProperty{}
+	thisMap.propertyIndexes = map[string]int{}
+
+	for i, propertyName := range thisMap.orderedProperties {
+
+		// new chained property into the map
+		property := &chainedProperty{owner: thisMap, name: propertyName}
+		thisMap.chainedProperties[propertyName] = property
+		thisMap.propertyIndexes[propertyName] = i + 1
+
+		// chaining this property with the preceding one
+		if i > 0 {
+			property.linkAfter(thisMap.chainedProperties[thisMap.orderedProperties[i-1]], false)
+		}
+	}
+
+	// returning this map
+	return thisMap
+}
@@ -0,0 +1,190 @@
+//------------------------------------------------------------------------------
+// the code here is about building the config object, and about maintaining
+// the config file from the common definition
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+)
+
+// what a configured color should look like
+var colorRegexp = regexp.MustCompile("^#[0-9A-Fa-f]{6}$")
+
+// reading the config from the given JSON content, if any; this happens before the scanning,
+// since some of the config is needed to build the common definition
+func readConfig(reader io.Reader) (*j2tConfig, error) {
+
+	// initialising the config object
+	config := &j2tConfig{}
+
+	// loading the config content, if present
+	if reader != nil {
+		configBytes, errRead := ioutil.ReadAll(reader)
+		if errRead != nil {
+			return nil, errRead
+		}
+		if errMarshall := json.Unmarshal(configBytes, config); errMarshall != nil {
+			return nil, errMarshall
+		}
+	}
+
+	return config, nil
+}
+
+// initialising the config of each property, from the configured columns, or else with computed colors
+func (commonDef *fileMap) initConfigMap(config *j2tConfig) error {
+
+	for index, property := range commonDef.orderedProperties {
+
+		// about to "compute" foregound and background colors
+		var bg string
+
+		// dealing with the top level, i.e. the first header line
+		if commonDef.parent == nil {
+			bg = getAdjustedColor(colors[index%len(colors)], 0, false)
+
+		} else {
+
+			// dealing with a sub-section level
+			level := 10
+			if index%2 == 1 {
+				level = 30
+			}
+			bg = getAdjustedColor(commonDef.parent.chainedProperties[commonDef.name].conf.background, level*commonDef.getDepth(), false)
+		}
+
+		// linking the prop to its config
+		newItem, errItem := config.newConfigItem(commonDef.chainedProperties[property], bg)
+		if errItem != nil {
+			return errItem
+		}
+		commonDef.chainedProperties[property].conf = newItem
+
+		// going deeper
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+			if errSub := subMap.initConfigMap(config); errSub != nil {
+				return errSub
+			}
+		}
+	}
+
+	return nil
+}
+
+// the config of the given property, given its default background color, and what's configured for its column, if anything
+func (config *j2tConfig) newConfigItem(prop *chainedProperty, background string) (*configItem, error) {
+
+	newItem := &configItem{background: background}
+
+	// the properties of a hidden section are hidden too
+	if prop.owner.parent != nil {
+		newItem.hidden = prop.owner.parent.chainedProperties[prop.owner.name].conf.hidden
+	}
+
+	// the configured values win
+	if column := config.getColumn(prop.getConfigPath()); column != nil {
+		if column.Color != "" {
+			if !colorRegexp.MatchString(column.Color) {
+				return nil, fmt.Errorf("invalid color '%s' for column '%s' in '%s'; expected something like '#1A5276'",
+					column.Color, column.Path, config.fileName)
+			}
+			newItem.background = column.Color
+		}
+		newItem.header = column.Header
+		newItem.hidden = newItem.hidden || column.Hide
+		newItem.width = column.Width
+		newItem.numberFormat = column.NumberFormat
+	}
+
+	return newItem, nil
+}
+
+// the config of each column - and section - of this common definition, and of its child sheets, in the order of the columns;
+// the width computed from the values is given apart from the configured one, so that it keeps being computed, unless configured
+func (commonDef *fileMap) getColumnConfigs() ([]*columnConfig, error) {
+
+	columns := []*columnConfig{}
+
+	for _, property := range commonDef.orderedProperties {
+
+		prop := commonDef.chainedProperties[property]
+		column := &columnConfig{
+			Path:  prop.getConfigPath(),
+			Color: prop.conf.background,
+		}
+		columns = append(columns, column)
+
+		// a section: its color only, and then the columns below
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+			subColumns, errSub := subMap.getColumnConfigs()
+			if errSub != nil {
+				return nil, errSub
+			}
+			columns = append(columns, subColumns...)
+
+			// a real column
+		} else {
+			if errDetect := prop.detectStat(); errDetect != nil {
+				return nil, errDetect
+			}
+			column.ComputedWidth = prop.getComputedWidth()
+			column.Stat = prop.statistic.kind
+		}
+	}
+
+	// the columns of the child sheets come last
+	for _, child := range commonDef.childSheets {
+		childColumns, errChild := child.commonDef.getColumnConfigs()
+		if errChild != nil {
+			return nil, errChild
+		}
+		columns = append(columns, childColumns...)
+	}
+
+	return columns, nil
+}
+
+// adding the given columns to the configured ones, if not there yet, right after the column preceding them;
+// the columns already configured are left untouched. Returns true if some columns have been added
+func (config *j2tConfig) addColumns(columns []*columnConfig) bool {
+
+	// the index will have to be rebuilt
+	config.columnIndex = nil
+
+	// indexing the already configured columns
+	configured := map[path]bool{}
+	for _, column := range config.Columns {
+		configured[column.Path] = true
+	}
+
+	added := false
+	position := 0 // where to insert the next missing column
+
+	for _, column := range columns {
+
+		// an already configured column: the following missing ones come after it
+		if configured[column.Path] {
+			for i, configuredColumn := range config.Columns {
+				if configuredColumn.Path == column.Path {
+					position = i + 1
+					break
+				}
+			}
+			continue
+		}
+
+		// a missing column: inserting it
+		config.Columns = append(config.Columns[:position], append([]*columnConfig{column}, config.Columns[position:]...)...)
+		configured[column.Path] = true
+		position++
+		added = true
+	}
+
+	return added
+}
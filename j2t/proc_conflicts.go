@@ -0,0 +1,336 @@
+//------------------------------------------------------------------------------
+// the code here resolves the type conflicts between the JSON maps, i.e. the
+// properties not having the same type in all of them, as configured per path
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// how a type conflict gets resolved
+type conflictPolicy string
+
+const (
+	conflictPolicySTRING conflictPolicy = "string" // all the values become text; the objects become JSON text
+	conflictPolicyNUMBER conflictPolicy = "number" // the numeric texts become numbers; the other values are blanked
+	conflictPolicyFIRST  conflictPolicy = "first"  // the type found first is kept; the other values are blanked
+	conflictPolicySPLIT  conflictPolicy = "split"  // 1 column per type, e.g. "prop (number)" and "prop (text)"
+)
+
+// how many files are named, at most, in the conflict summaries
+const maxSummaryFiles = 5
+
+// a valid JSON number, to turn numeric texts into numbers
+var numberRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// the kinds found for 1 property path, and the files where they were found
+type typeConflict struct {
+	path       path
+	kinds      []reflect.Kind            // in the order they've been found
+	files      map[reflect.Kind][]string // the files where each kind has been found
+	nulls      bool                      // true if the property has been found null too
+	declared   bool                      // true if the property's type is declared by a schema, its kind coming first
+	policy     conflictPolicy
+	configured bool // false if the policy is the default one, in continue mode, or the schema's type
+}
+
+// resolving the type conflicts between the given JSON maps, as configured; the config paths are prefixed
+// with the given one, for the child sheets. Without a configured policy, the type declared by the seed - if
+// any - is kept; otherwise, a conflict is an error - unless in continue mode, where the type found first is
+// kept. The resolved conflicts are returned.
+func resolveConflicts(config *j2tConfig, jsonMaps []*fileMap, configPrefix path, continueMode bool) ([]*typeConflict, error) {
+
+	resolved := []*typeConflict{}
+
+	// resolving a conflict can only bring out deeper ones, so we go level by level, starting with the shallowest
+	for {
+
+		// finding out all the kinds of all the properties
+		kinds := map[path]*typeConflict{}
+		paths := []path{}
+		for _, jsonMap := range jsonMaps {
+			jsonMap.surveyKinds(configPrefix, kinds, &paths)
+		}
+
+		// keeping the conflicts found at the shallowest level
+		conflicts := map[path]*typeConflict{}
+		depth := -1
+		for _, propPath := range paths {
+			conflict := kinds[propPath]
+			if len(conflict.kinds) < 2 {
+				continue
+			}
+			if conflictDepth := strings.Count(string(propPath), "/"); depth < 0 || conflictDepth < depth {
+				conflicts, depth = map[path]*typeConflict{}, conflictDepth
+			} else if conflictDepth > depth {
+				continue
+			}
+
+			// a conflict needs a policy
+			policy, errPolicy := config.getConflictPolicy(propPath)
+			if errPolicy != nil {
+				return nil, errPolicy
+			}
+			if policy == "" && conflict.declared {
+				policy = conflictPolicyFIRST
+			} else if policy == "" {
+				if !continueMode {
+					return nil, &TypeConflictError{
+						Path:      string(propPath),
+						File:      conflict.files[conflict.kinds[1]][0],
+						Type:      conflict.kinds[0].String(),
+						FoundType: conflict.kinds[1].String(),
+					}
+				}
+				policy = conflictPolicyFIRST
+			} else {
+				conflict.configured = true
+			}
+			conflict.policy = policy
+			conflicts[propPath] = conflict
+		}
+		if len(conflicts) == 0 {
+			break
+		}
+
+		// resolving them
+		for _, jsonMap := range jsonMaps {
+			if errResolve := jsonMap.resolveConflicts(configPrefix, conflicts); errResolve != nil {
+				return nil, fmt.Errorf("error in file '%s': %w", jsonMap.root().name, errResolve)
+			}
+		}
+		for _, conflict := range conflicts {
+			resolved = append(resolved, conflict)
+		}
+	}
+
+	return resolved, nil
+}
+
+// the policy configured for the given path, if any
+func (config *j2tConfig) getConflictPolicy(propPath path) (conflictPolicy, error) {
+	for _, conflictConf := range config.TypeConflicts {
+		if conflictConf.Path == propPath {
+			switch conflictConf.Policy {
+			case conflictPolicySTRING, conflictPolicyNUMBER, conflictPolicyFIRST, conflictPolicySPLIT:
+				return conflictConf.Policy, nil
+			}
+			return "", fmt.Errorf("unknown policy '%s' configured for the type conflicts of '%s'", conflictConf.Policy, propPath)
+		}
+	}
+	return "", nil
+}
+
+// recursively gathering the kinds of this map's properties, the null values being only noted;
+// the paths are listed in the order they're found. A seed declares kinds, but it's not a file
+func (thisMap *fileMap) surveyKinds(mapPath path, kinds map[path]*typeConflict, paths *[]path) {
+
+	for _, propertyName := range thisMap.orderedProperties {
+
+		propPath := mapPath + path(propertyName)
+
+		conflict := kinds[propPath]
+		if conflict == nil {
+			conflict = &typeConflict{path: propPath, files: map[reflect.Kind][]string{}}
+			kinds[propPath] = conflict
+			*paths = append(*paths, propPath)
+		}
+		if kind := thisMap.getPropertyKind(propertyName); thisMap.root().seed {
+			if kind != reflect.Invalid {
+				conflict.kinds = append(conflict.kinds, kind)
+				conflict.declared = true
+			}
+		} else if kind != reflect.Invalid {
+			if conflict.files[kind] == nil && (!conflict.declared || conflict.kinds[0] != kind) {
+				conflict.kinds = append(conflict.kinds, kind)
+			}
+			conflict.files[kind] = append(conflict.files[kind], thisMap.root().name)
+		} else {
+			conflict.nulls = true
+		}
+
+		if subMap := thisMap.subMaps[propertyName]; subMap != nil {
+			subMap.surveyKinds(propPath+"/", kinds, paths)
+		}
+	}
+}
+
+// recursively applying the given conflicts' policies to this map's properties
+func (thisMap *fileMap) resolveConflicts(mapPath path, conflicts map[path]*typeConflict) error {
+
+	// copying the properties, since they might change along the way
+	propertyNames := append([]string{}, thisMap.orderedProperties...)
+
+	for _, propertyName := range propertyNames {
+
+		propPath := mapPath + path(propertyName)
+
+		if conflict := conflicts[propPath]; conflict != nil {
+
+			// resolving this conflict
+			if errResolve := thisMap.resolveConflict(propertyName, conflict); errResolve != nil {
+				return errResolve
+			}
+
+		} else if subMap := thisMap.subMaps[propertyName]; subMap != nil {
+
+			// going deeper
+			if errResolve := subMap.resolveConflicts(propPath+"/", conflicts); errResolve != nil {
+				return errResolve
+			}
+		}
+	}
+
+	return nil
+}
+
+// applying a conflict's policy to 1 property of this map
+func (thisMap *fileMap) resolveConflict(propertyName string, conflict *typeConflict) error {
+
+	kind := thisMap.getPropertyKind(propertyName)
+	if kind == reflect.Invalid {
+		return nil
+	}
+
+	switch conflict.policy {
+
+	case conflictPolicySTRING:
+		if kind != reflect.String {
+			text, errText := thisMap.valueToString(propertyName)
+			if errText != nil {
+				return fmt.Errorf("could not turn '%s' into text: %w", conflict.path, errText)
+			}
+			thisMap.setSimpleValue(propertyName, text)
+		}
+
+	case conflictPolicyNUMBER:
+		if text, isText := thisMap.values[propertyName].(string); isText && numberRegexp.MatchString(strings.TrimSpace(text)) {
+			thisMap.values[propertyName] = json.Number(strings.TrimSpace(text))
+		} else if kind != reflect.Float64 {
+			thisMap.setSimpleValue(propertyName, nil)
+		}
+
+	case conflictPolicyFIRST:
+		if kind != conflict.kinds[0] {
+			thisMap.setSimpleValue(propertyName, nil)
+		}
+
+	case conflictPolicySPLIT:
+		thisMap.renameProperty(propertyName, conflict.getSplitName(propertyName, kind))
+	}
+
+	return nil
+}
+
+// the name of the property holding the values of the given kind, for a conflict resolved by splitting
+func (conflict *typeConflict) getSplitName(propertyName string, kind reflect.Kind) string {
+	return fmt.Sprintf("%s (%s)", propertyName, kindName(kind))
+}
+
+// the name of the conflicting property, i.e. the last part of its path
+func (conflict *typeConflict) getName() string {
+	return string(conflict.path)[strings.LastIndex(string(conflict.path), "/")+1:]
+}
+
+// the names of the properties resulting from resolving this conflict, i.e. 1 more per kind when splitting, the null
+// values keeping the original name
+func (conflict *typeConflict) getResolvedNames() []string {
+
+	names := []string{conflict.getName()}
+	if conflict.policy != conflictPolicySPLIT {
+		return names
+	}
+
+	for _, kind := range conflict.kinds {
+		names = append(names, conflict.getSplitName(conflict.getName(), kind))
+	}
+
+	return names
+}
+
+// replacing a property's value - or submap - with a simple value
+func (thisMap *fileMap) setSimpleValue(propertyName string, value interface{}) {
+	delete(thisMap.subMaps, propertyName)
+	thisMap.values[propertyName] = value
+}
+
+// the text representation of a property's value; the submaps are represented as JSON
+func (thisMap *fileMap) valueToString(propertyName string) (string, error) {
+
+	if subMap := thisMap.subMaps[propertyName]; subMap != nil {
+		subMapBytes, errMarshal := subMap.MarshalJSON()
+		return string(subMapBytes), errMarshal
+	}
+
+	switch value := thisMap.values[propertyName].(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case json.Number:
+		return value.String(), nil
+	}
+
+	return fmt.Sprintf("%v", thisMap.values[propertyName]), nil
+}
+
+// the name of a kind, as shown to the users
+func kindName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "text"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Map:
+		return "object"
+	}
+	return kind.String()
+}
+
+// summing up the resolved conflicts, with the files involved
+func getConflictSummary(resolved []*typeConflict) []string {
+
+	if len(resolved) == 0 {
+		return nil
+	}
+
+	conflicts := append([]*typeConflict{}, resolved...)
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].path < conflicts[j].path
+	})
+
+	summary := []string{fmt.Sprintf("%d type conflict(s) resolved:", len(conflicts))}
+	for _, conflict := range conflicts {
+		policy := string(conflict.policy)
+		if conflict.declared && !conflict.configured {
+			policy += ", as declared by the schema"
+		} else if !conflict.configured {
+			policy += ", by default"
+		}
+		kindSummaries := []string{}
+		for _, kind := range conflict.kinds {
+			if len(conflict.files[kind]) > 0 {
+				kindSummaries = append(kindSummaries, fmt.Sprintf("%s in %s", kindName(kind), listFiles(conflict.files[kind])))
+			}
+		}
+		summary = append(summary, fmt.Sprintf("- '%s' (%s): %s", conflict.path, policy, strings.Join(kindSummaries, "; ")))
+	}
+
+	return summary
+}
+
+// listing the given files in a summary, up to a point
+func listFiles(files []string) string {
+	if len(files) > maxSummaryFiles {
+		return fmt.Sprintf("'%s', and %d more", strings.Join(files[:maxSummaryFiles], "', '"), len(files)-maxSummaryFiles)
+	}
+	return "'" + strings.Join(files, "', '") + "'"
+}
@@ -0,0 +1,639 @@
+//------------------------------------------------------------------------------
+// evaluating the formulae of the new columns, for the outputs that cannot
+// hold Excel formulae; only a subset of Excel's syntax is supported here:
+// numbers, "strings", TRUE/FALSE, {property/path} references, the operators
+// + - * / ^ & = <> < > <= >= and a few common functions
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// a formula error, which is shown as a value, the Excel way - e.g. "#DIV/0!"
+type formulaError string
+
+func (thisError formulaError) Error() string {
+	return string(thisError)
+}
+
+const (
+	formulaErrorDIV   formulaError = "#DIV/0!"
+	formulaErrorVALUE formulaError = "#VALUE!"
+	formulaErrorNAME  formulaError = "#NAME?"
+)
+
+// a node in the parsed formula; evaluating it gives a float64, a string, a bool, or nil
+type formulaNode interface {
+	eval(resolve func(path) (interface{}, error)) (interface{}, error)
+}
+
+type formulaLiteral struct {
+	value interface{}
+}
+
+type formulaReference struct {
+	path path
+}
+
+type formulaUnary struct {
+	operator string
+	operand  formulaNode
+}
+
+type formulaBinary struct {
+	operator string
+	left     formulaNode
+	right    formulaNode
+}
+
+type formulaCall struct {
+	function string
+	args     []formulaNode
+}
+
+//------------------------------------------------------------------------------
+// Parsing
+//------------------------------------------------------------------------------
+
+// the formula parser's state
+type formulaParser struct {
+	formula  []rune
+	position int
+}
+
+// parsing a whole formula, i.e. what's configured for a new column - without the leading '='
+func parseFormula(formula string) (formulaNode, error) {
+
+	parser := &formulaParser{formula: []rune(strings.TrimPrefix(strings.TrimSpace(formula), "="))}
+
+	node, errParse := parser.parseComparison()
+	if errParse != nil {
+		return nil, errParse
+	}
+
+	if parser.skipSpaces(); parser.position < len(parser.formula) {
+		return nil, fmt.Errorf("unexpected '%c' at position %d in formula: %s", parser.formula[parser.position], parser.position, formula)
+	}
+
+	return node, nil
+}
+
+// skipping the blank characters
+func (parser *formulaParser) skipSpaces() {
+	for parser.position < len(parser.formula) && unicode.IsSpace(parser.formula[parser.position]) {
+		parser.position++
+	}
+}
+
+// consuming one of the given operators, if it's next; the longest operators must come first
+func (parser *formulaParser) nextOperator(operators ...string) string {
+	parser.skipSpaces()
+	for _, operator := range operators {
+		end := parser.position + len([]rune(operator))
+		if end <= len(parser.formula) && string(parser.formula[parser.position:end]) == operator {
+			parser.position = end
+			return operator
+		}
+	}
+	return ""
+}
+
+// parsing a left-associative sequence of binary operations
+func (parser *formulaParser) parseBinary(parseOperand func() (formulaNode, error), operators ...string) (formulaNode, error) {
+
+	left, errLeft := parseOperand()
+	if errLeft != nil {
+		return nil, errLeft
+	}
+
+	for operator := parser.nextOperator(operators...); operator != ""; operator = parser.nextOperator(operators...) {
+		right, errRight := parseOperand()
+		if errRight != nil {
+			return nil, errRight
+		}
+		left = &formulaBinary{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (parser *formulaParser) parseComparison() (formulaNode, error) {
+	return parser.parseBinary(parser.parseConcatenation, "<>", "<=", ">=", "=", "<", ">")
+}
+
+func (parser *formulaParser) parseConcatenation() (formulaNode, error) {
+	return parser.parseBinary(parser.parseAddition, "&")
+}
+
+func (parser *formulaParser) parseAddition() (formulaNode, error) {
+	return parser.parseBinary(parser.parseMultiplication, "+", "-")
+}
+
+func (parser *formulaParser) parseMultiplication() (formulaNode, error) {
+	return parser.parseBinary(parser.parsePower, "*", "/")
+}
+
+func (parser *formulaParser) parsePower() (formulaNode, error) {
+	return parser.parseBinary(parser.parseUnary, "^")
+}
+
+func (parser *formulaParser) parseUnary() (formulaNode, error) {
+	if operator := parser.nextOperator("-", "+"); operator != "" {
+		operand, errOperand := parser.parseUnary()
+		if errOperand != nil {
+			return nil, errOperand
+		}
+		return &formulaUnary{operator: operator, operand: operand}, nil
+	}
+	return parser.parsePrimary()
+}
+
+func (parser *formulaParser) parsePrimary() (formulaNode, error) {
+
+	parser.skipSpaces()
+	if parser.position >= len(parser.formula) {
+		return nil, fmt.Errorf("unexpected end of formula: %s", string(parser.formula))
+	}
+
+	start := parser.position
+	current := parser.formula[start]
+
+	switch {
+
+	// a sub-expression
+	case current == '(':
+		parser.position++
+		node, errNode := parser.parseComparison()
+		if errNode != nil {
+			return nil, errNode
+		}
+		if parser.nextOperator(")") == "" {
+			return nil, fmt.Errorf("missing ')' in formula: %s", string(parser.formula))
+		}
+		return node, nil
+
+	// a property reference
+	case current == '{':
+		end := strings.IndexRune(string(parser.formula[start:]), '}')
+		if end < 0 {
+			return nil, fmt.Errorf("missing '}' in formula: %s", string(parser.formula))
+		}
+		reference := []rune(string(parser.formula[start:])[1:end])
+		parser.position = start + len(reference) + 2
+		return &formulaReference{path: path(reference)}, nil
+
+	// a string, where a double quote is escaped by doubling it
+	case current == '"':
+		value := []rune{}
+		for parser.position++; parser.position < len(parser.formula); parser.position++ {
+			if parser.formula[parser.position] == '"' {
+				if parser.position+1 < len(parser.formula) && parser.formula[parser.position+1] == '"' {
+					parser.position++
+				} else {
+					parser.position++
+					return &formulaLiteral{value: string(value)}, nil
+				}
+			}
+			value = append(value, parser.formula[parser.position])
+		}
+		return nil, fmt.Errorf("unterminated string in formula: %s", string(parser.formula))
+
+	// a number
+	case unicode.IsDigit(current) || current == '.':
+		for parser.position < len(parser.formula) &&
+			(unicode.IsDigit(parser.formula[parser.position]) || parser.formula[parser.position] == '.') {
+			parser.position++
+		}
+		value, errParse := strconv.ParseFloat(string(parser.formula[start:parser.position]), 64)
+		if errParse != nil {
+			return nil, fmt.Errorf("invalid number in formula: %s", string(parser.formula))
+		}
+		if parser.nextOperator("%") != "" {
+			value = value / 100
+		}
+		return &formulaLiteral{value: value}, nil
+
+	// a function call, or a boolean
+	case unicode.IsLetter(current):
+		for parser.position < len(parser.formula) &&
+			(unicode.IsLetter(parser.formula[parser.position]) || unicode.IsDigit(parser.formula[parser.position]) || parser.formula[parser.position] == '.') {
+			parser.position++
+		}
+		name := strings.ToUpper(string(parser.formula[start:parser.position]))
+		if parser.nextOperator("(") == "" {
+			switch name {
+			case "TRUE":
+				return &formulaLiteral{value: true}, nil
+			case "FALSE":
+				return &formulaLiteral{value: false}, nil
+			}
+			return nil, fmt.Errorf("unknown name '%s' in formula: %s", name, string(parser.formula))
+		}
+		call := &formulaCall{function: name}
+		if _, known := formulaFunctions[name]; !known {
+			return call, parser.skipArguments(name)
+		}
+		if parser.nextOperator(")") != "" {
+			return call, nil
+		}
+		for {
+			arg, errArg := parser.parseComparison()
+			if errArg != nil {
+				return nil, errArg
+			}
+			call.args = append(call.args, arg)
+			if parser.nextOperator(",", ";") == "" {
+				break
+			}
+		}
+		if parser.nextOperator(")") == "" {
+			return nil, fmt.Errorf("missing ')' after the arguments of '%s' in formula: %s", name, string(parser.formula))
+		}
+		return call, nil
+	}
+
+	return nil, fmt.Errorf("unexpected '%c' at position %d in formula: %s", current, start, string(parser.formula))
+}
+
+// skipping the arguments of an unsupported function - which may not be parsable, e.g. a cell range - up to the closing
+// parenthesis, since such a call evaluates to an error, whatever its arguments
+func (parser *formulaParser) skipArguments(name string) error {
+	depth := 1
+	for inString, inReference := false, false; parser.position < len(parser.formula); parser.position++ {
+		switch current := parser.formula[parser.position]; {
+		case inString:
+			inString = current != '"'
+		case inReference:
+			inReference = current != '}'
+		case current == '"':
+			inString = true
+		case current == '{':
+			inReference = true
+		case current == '(':
+			depth++
+		case current == ')':
+			if depth--; depth == 0 {
+				parser.position++
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("missing ')' after the arguments of '%s' in formula: %s", name, string(parser.formula))
+}
+
+//------------------------------------------------------------------------------
+// Evaluating
+//------------------------------------------------------------------------------
+
+func (node *formulaLiteral) eval(resolve func(path) (interface{}, error)) (interface{}, error) {
+	return node.value, nil
+}
+
+func (node *formulaReference) eval(resolve func(path) (interface{}, error)) (interface{}, error) {
+	return resolve(node.path)
+}
+
+func (node *formulaUnary) eval(resolve func(path) (interface{}, error)) (interface{}, error) {
+	operand, errEval := evalNumber(node.operand, resolve)
+	if errEval != nil {
+		return nil, errEval
+	}
+	if node.operator == "-" {
+		return -operand, nil
+	}
+	return operand, nil
+}
+
+func (node *formulaBinary) eval(resolve func(path) (interface{}, error)) (interface{}, error) {
+
+	left, errLeft := node.left.eval(resolve)
+	if errLeft != nil {
+		return nil, errLeft
+	}
+	right, errRight := node.right.eval(resolve)
+	if errRight != nil {
+		return nil, errRight
+	}
+
+	switch node.operator {
+
+	case "&":
+		return formulaString(left) + formulaString(right), nil
+
+	case "=", "<>", "<", ">", "<=", ">=":
+		comparison := compareFormulaValues(left, right)
+		switch node.operator {
+		case "=":
+			return comparison == 0, nil
+		case "<>":
+			return comparison != 0, nil
+		case "<":
+			return comparison < 0, nil
+		case ">":
+			return comparison > 0, nil
+		case "<=":
+			return comparison <= 0, nil
+		}
+		return comparison >= 0, nil
+	}
+
+	leftNumber, errLeftNumber := formulaNumber(left)
+	if errLeftNumber != nil {
+		return nil, errLeftNumber
+	}
+	rightNumber, errRightNumber := formulaNumber(right)
+	if errRightNumber != nil {
+		return nil, errRightNumber
+	}
+
+	switch node.operator {
+	case "+":
+		return leftNumber + rightNumber, nil
+	case "-":
+		return leftNumber - rightNumber, nil
+	case "*":
+		return leftNumber * rightNumber, nil
+	case "/":
+		if rightNumber == 0 {
+			return nil, formulaErrorDIV
+		}
+		return leftNumber / rightNumber, nil
+	}
+
+	return math.Pow(leftNumber, rightNumber), nil
+}
+
+func (node *formulaCall) eval(resolve func(path) (interface{}, error)) (interface{}, error) {
+	function, supported := formulaFunctions[node.function]
+	if !supported {
+		return nil, formulaErrorNAME
+	}
+	return function(node.args, resolve)
+}
+
+// evaluating a node as a number
+func evalNumber(node formulaNode, resolve func(path) (interface{}, error)) (float64, error) {
+	value, errEval := node.eval(resolve)
+	if errEval != nil {
+		return 0, errEval
+	}
+	return formulaNumber(value)
+}
+
+// evaluating a node as a boolean
+func evalBool(node formulaNode, resolve func(path) (interface{}, error)) (bool, error) {
+	value, errEval := node.eval(resolve)
+	if errEval != nil {
+		return false, errEval
+	}
+	switch typedValue := value.(type) {
+	case bool:
+		return typedValue, nil
+	case string:
+		switch strings.ToUpper(typedValue) {
+		case "TRUE":
+			return true, nil
+		case "FALSE", "":
+			return false, nil
+		}
+		return false, formulaErrorVALUE
+	}
+	number, errNumber := formulaNumber(value)
+	return number != 0, errNumber
+}
+
+// seeing a value as a number, the Excel way: empty is 0, TRUE is 1, and text must be numeric
+func formulaNumber(value interface{}) (float64, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return typedValue, nil
+	case bool:
+		if typedValue {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if strings.TrimSpace(typedValue) == "" {
+			return 0, nil
+		}
+		number, errParse := strconv.ParseFloat(strings.TrimSpace(typedValue), 64)
+		if errParse != nil {
+			return 0, formulaErrorVALUE
+		}
+		return number, nil
+	}
+	return 0, formulaErrorVALUE
+}
+
+// seeing a value as a string, the Excel way
+func formulaString(value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	case bool:
+		if typedValue {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// comparing 2 values: numbers together, or else as case-insensitive strings
+func compareFormulaValues(left, right interface{}) int {
+	leftNumber, errLeft := formulaNumber(left)
+	rightNumber, errRight := formulaNumber(right)
+	_, leftIsString := left.(string)
+	_, rightIsString := right.(string)
+	if errLeft == nil && errRight == nil && !leftIsString && !rightIsString {
+		switch {
+		case leftNumber < rightNumber:
+			return -1
+		case leftNumber > rightNumber:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToUpper(formulaString(left)), strings.ToUpper(formulaString(right)))
+}
+
+//------------------------------------------------------------------------------
+// Functions
+//------------------------------------------------------------------------------
+
+type formulaFunction func(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error)
+
+var formulaFunctions map[string]formulaFunction
+
+func init() {
+	formulaFunctions = map[string]formulaFunction{
+		"IF":          formulaIF,
+		"AND":         formulaAND,
+		"OR":          formulaOR,
+		"NOT":         formulaNOT,
+		"ABS":         formulaMath1(math.Abs),
+		"SQRT":        formulaMath1(math.Sqrt),
+		"INT":         formulaMath1(math.Floor),
+		"ROUND":       formulaROUND,
+		"SUM":         formulaAggregate(sumOf),
+		"AVERAGE":     formulaAggregate(func(numbers []float64) float64 { return sumOf(numbers) / float64(len(numbers)) }),
+		"MIN":         formulaAggregate(func(numbers []float64) float64 { return extremumOf(numbers, math.Min) }),
+		"MAX":         formulaAggregate(func(numbers []float64) float64 { return extremumOf(numbers, math.Max) }),
+		"CONCATENATE": formulaCONCATENATE,
+		"LEN":         formulaText(func(text string) interface{} { return float64(len([]rune(text))) }),
+		"UPPER":       formulaText(func(text string) interface{} { return strings.ToUpper(text) }),
+		"LOWER":       formulaText(func(text string) interface{} { return strings.ToLower(text) }),
+		"TRIM":        formulaText(func(text string) interface{} { return strings.Join(strings.Fields(text), " ") }),
+	}
+}
+
+func formulaIF(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, formulaErrorVALUE
+	}
+	condition, errCondition := evalBool(args[0], resolve)
+	if errCondition != nil {
+		return nil, errCondition
+	}
+	if condition {
+		return args[1].eval(resolve)
+	}
+	if len(args) == 3 {
+		return args[2].eval(resolve)
+	}
+	return false, nil
+}
+
+func formulaAND(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+	for _, arg := range args {
+		value, errValue := evalBool(arg, resolve)
+		if errValue != nil || !value {
+			return false, errValue
+		}
+	}
+	return len(args) > 0, nil
+}
+
+func formulaOR(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+	for _, arg := range args {
+		value, errValue := evalBool(arg, resolve)
+		if errValue != nil || value {
+			return value, errValue
+		}
+	}
+	return false, nil
+}
+
+func formulaNOT(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, formulaErrorVALUE
+	}
+	value, errValue := evalBool(args[0], resolve)
+	return !value, errValue
+}
+
+func formulaROUND(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, formulaErrorVALUE
+	}
+	value, errValue := evalNumber(args[0], resolve)
+	if errValue != nil {
+		return nil, errValue
+	}
+	digits, errDigits := evalNumber(args[1], resolve)
+	if errDigits != nil {
+		return nil, errDigits
+	}
+	factor := math.Pow(10, math.Trunc(digits))
+	return math.Round(value*factor) / factor, nil
+}
+
+func formulaCONCATENATE(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+	result := ""
+	for _, arg := range args {
+		value, errValue := arg.eval(resolve)
+		if errValue != nil {
+			return nil, errValue
+		}
+		result = result + formulaString(value)
+	}
+	return result, nil
+}
+
+// a function with 1 numeric argument
+func formulaMath1(fn func(float64) float64) formulaFunction {
+	return func(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, formulaErrorVALUE
+		}
+		value, errValue := evalNumber(args[0], resolve)
+		if errValue != nil {
+			return nil, errValue
+		}
+		return fn(value), nil
+	}
+}
+
+// a function with 1 text argument
+func formulaText(fn func(string) interface{}) formulaFunction {
+	return func(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, formulaErrorVALUE
+		}
+		value, errValue := args[0].eval(resolve)
+		if errValue != nil {
+			return nil, errValue
+		}
+		return fn(formulaString(value)), nil
+	}
+}
+
+// a function over several numbers; like Excel, the empty and text values are ignored
+func formulaAggregate(fn func([]float64) float64) formulaFunction {
+	return func(args []formulaNode, resolve func(path) (interface{}, error)) (interface{}, error) {
+		numbers := []float64{}
+		for _, arg := range args {
+			value, errValue := arg.eval(resolve)
+			if errValue != nil {
+				return nil, errValue
+			}
+			switch typedValue := value.(type) {
+			case float64:
+				numbers = append(numbers, typedValue)
+			case bool:
+				number, _ := formulaNumber(typedValue)
+				numbers = append(numbers, number)
+			}
+		}
+		if len(numbers) == 0 {
+			return float64(0), nil
+		}
+		return fn(numbers), nil
+	}
+}
+
+func sumOf(numbers []float64) float64 {
+	sum := 0.0
+	for _, number := range numbers {
+		sum = sum + number
+	}
+	return sum
+}
+
+func extremumOf(numbers []float64, pick func(float64, float64) float64) float64 {
+	result := numbers[0]
+	for _, number := range numbers[1:] {
+		result = pick(result, number)
+	}
+	return result
+}
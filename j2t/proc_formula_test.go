@@ -0,0 +1,107 @@
+//------------------------------------------------------------------------------
+// testing the evaluation of the new columns' formulae, for the outputs that
+// cannot hold Excel formulae, e.g. CSV
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"testing"
+)
+
+func TestFormulae(t *testing.T) {
+
+	// the values of the referenced properties
+	values := map[path]interface{}{
+		"price":        12.5,
+		"qty":          4.0,
+		"zero":         0.0,
+		"name":         "alpha",
+		"active":       true,
+		"address/city": "  Paris  ",
+		"missing":      nil,
+	}
+	resolve := func(propPath path) (interface{}, error) {
+		return values[propPath], nil
+	}
+
+	cases := []struct {
+		formula string
+		want    interface{}
+	}{
+		// numbers & operators
+		{"{price}*{qty}+1", 51.0},
+		{"={price} * ({qty} + 1)", 62.5},
+		{"2^3^2", 64.0},
+		{"-{qty}^2", 16.0},
+		{"50%*{qty}", 2.0},
+		{"{price}/{zero}", formulaErrorDIV},
+		{"{name}+1", formulaErrorVALUE},
+		{"{missing}+1", 1.0},
+
+		// text
+		{`{name} & "-" & {qty}`, "alpha-4"},
+		{`"say ""hi"""`, `say "hi"`},
+		{`UPPER({name})`, "ALPHA"},
+		{`TRIM({address/city})`, "Paris"},
+		{`LEN({name})`, 5.0},
+		{`CONCATENATE({name}, " ", {active})`, "alpha TRUE"},
+
+		// comparisons & logic
+		{`{price}>10`, true},
+		{`{name}="ALPHA"`, true},
+		{`{name}<>"beta"`, true},
+		{`IF({qty}>=4, "many", "few")`, "many"},
+		{`IF(AND({active}, NOT({qty}<1)), 1, 0)`, 1.0},
+		{`OR(FALSE, {zero})`, false},
+
+		// aggregates & rounding
+		{`SUM({price}, {qty}, 1)`, 17.5},
+		{`AVERAGE({price}; {qty})`, 8.25},
+		{`MAX({price}, {qty})`, 12.5},
+		{`ROUND({price}/{qty}, 1)`, 3.1},
+		{`INT(-{price})`, -13.0},
+
+		// the functions that are not supported give #NAME?, whatever their arguments
+		{`VLOOKUP({name}, A1:B12, 2, FALSE)`, formulaErrorNAME},
+		{`1 + XLOOKUP("a(", {name})`, formulaErrorNAME},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.formula, func(t *testing.T) {
+
+			expression, errParse := parseFormula(testCase.formula)
+			if errParse != nil {
+				t.Fatalf("could not parse the formula: %v", errParse)
+			}
+
+			got, errEval := expression.eval(resolve)
+			if formulaErr, isFormulaError := errEval.(formulaError); isFormulaError {
+				got, errEval = formulaErr, nil
+			}
+			if errEval != nil {
+				t.Fatalf("could not evaluate the formula: %v", errEval)
+			}
+			if got != testCase.want {
+				t.Errorf("expected %#v, got %#v", testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestFormulaSyntaxErrors(t *testing.T) {
+	for _, formula := range []string{
+		"{price}*",
+		"({price}+1",
+		"{price",
+		`"unterminated`,
+		"price*2",
+		"SUM({price}, 1",
+		"VLOOKUP({name}, A1:B2",
+		"1 2",
+	} {
+		if _, errParse := parseFormula(formula); errParse == nil {
+			t.Errorf("expected a syntax error for formula: %s", formula)
+		}
+	}
+}
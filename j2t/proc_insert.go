@@ -2,7 +2,7 @@
 // inserting the new columns within the common definition
 //------------------------------------------------------------------------------
 
-package main
+package j2t
 
 import (
 	"fmt"
@@ -14,13 +14,13 @@ func (commonDef *fileMap) insertNewColumns(config *j2tConfig) error {
 
 	if config.NewColumns != nil {
 		for _, newColumn := range config.NewColumns {
-			if errInsert := commonDef.insertNewColumn(newColumn); errInsert != nil {
-				err("error while adding an extra column: %s", errInsert)
+			if errInsert := commonDef.insertNewColumn(config, newColumn); errInsert != nil {
+				return errInsert
 			}
 		}
 	}
 
-	if debugMode {
+	if commonDef.debugMode {
 		commonDef.reorder()
 		commonDef.displayOrdered(0, showKind)
 	}
@@ -29,13 +29,12 @@ func (commonDef *fileMap) insertNewColumns(config *j2tConfig) error {
 }
 
 // initialising a new inserted column
-func (commonDef *fileMap) insertNewColumn(newCol *newColumnConfig) error {
+func (commonDef *fileMap) insertNewColumn(config *j2tConfig, newCol *newColumnConfig) error {
 
 	// the column we're inserting right after
 	previousProp := commonDef.allChainedProperties[newCol.PutAfter]
 	if previousProp == nil {
-		return fmt.Errorf("error in the configuration of the new column '%s': this property does not seem to exist: %s!"+
-			" You might wanna watch for typos", newCol.Name, newCol.PutAfter)
+		return &ConfigPathError{Path: string(newCol.PutAfter), File: config.fileName, Column: newCol.Name}
 	}
 
 	// getting the map we're going to insert into
@@ -58,10 +57,12 @@ func (commonDef *fileMap) insertNewColumn(newCol *newColumnConfig) error {
 	// global registration of the property
 	commonDef.register(newProperty)
 
-	// initialising the config
-	newProperty.conf = &configItem{
-		background: getAdjustedColor(previousProp.conf.background, 2, false), // almost keeping the same color as the one before
+	// initialising the config - almost keeping the same color as the one before, if not configured
+	conf, errConf := config.newConfigItem(newProperty, getAdjustedColor(previousProp.conf.background, 2, false))
+	if errConf != nil {
+		return errConf
 	}
+	newProperty.conf = conf
 
 	// initialising the stat - this will depend on the computation definition later on (when we have time)
 	newProperty.statistic = &stat{
@@ -88,8 +89,7 @@ func (commonDef *fileMap) insertNewColumn(newCol *newColumnConfig) error {
 			currentPropReading = false
 			prop := commonDef.getProp(path(currentPropName))
 			if prop == nil {
-				return fmt.Errorf("error in the configuration of the new column '%s': this property does not seem to exist: %s!"+
-					" You might wanna watch for typos", newCol.Name, string(currentPropName))
+				return &ConfigPathError{Path: string(currentPropName), File: config.fileName, Column: newCol.Name}
 			}
 			newCol.columns = append(newCol.columns, prop)
 			currentPropName = nil
@@ -105,5 +105,12 @@ func (commonDef *fileMap) insertNewColumn(newCol *newColumnConfig) error {
 	// at this point, we should have finished building the formattable formula
 	newCol.formattableFormula = string(formattableFormula)
 
+	// parsing the formula once for all, to evaluate it where a file cannot hold formulae - failing before any file is written
+	expression, errParse := parseFormula(newCol.Formula)
+	if errParse != nil {
+		return fmt.Errorf("error in the formula of column '%s' in '%s': %w", newCol.Name, config.fileName, errParse)
+	}
+	newCol.expression = expression
+
 	return nil
 }
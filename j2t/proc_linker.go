@@ -2,7 +2,7 @@
 // the code here is about how we link a new property into an existing definition
 //------------------------------------------------------------------------------
 
-package main
+package j2t
 
 import "fmt"
 
@@ -19,8 +19,8 @@ func (newProp *chainedProperty) link(originalProp *chainedProperty) {
 		panic(fmt.Errorf("original property '%s' was chained to nothing", originalProp))
 	}
 
-	log("\n+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++")
-	log("==> trying to link property: %s", newProp)
+	newProp.owner.log("\n+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++")
+	newProp.owner.log("==> trying to link property: %s", newProp)
 
 	// the properties already chained in the common definition
 	commonProperties := newProp.owner.chainedProperties
@@ -29,7 +29,7 @@ func (newProp *chainedProperty) link(originalProp *chainedProperty) {
 	if originalProp.previous == nil {
 
 		root := newProp.owner.oneChainedProperty().root()
-		log("--> linking before root %s", root)
+		newProp.owner.log("--> linking before root %s", root)
 		root.linkAfter(newProp, true)
 		return
 	}
@@ -38,28 +38,28 @@ func (newProp *chainedProperty) link(originalProp *chainedProperty) {
 	// NB: it exists since we necessarily dealt with it previously
 	firstPossiblePreviousProp := commonProperties[originalProp.previous.name]
 
-	log("--> first previous = %s", firstPossiblePreviousProp)
+	newProp.owner.log("--> first previous = %s", firstPossiblePreviousProp)
 	// what's the max after we can reach ?
 	var lastPossibleNextProp *chainedProperty
 
 	// let's look at the next property that exists in the common definition
 	for currentNext := originalProp.next; currentNext != nil && lastPossibleNextProp == nil; currentNext = currentNext.next {
-		log("--> last next scan = %s", currentNext)
+		newProp.owner.log("--> last next scan = %s", currentNext)
 		lastPossibleNextProp = commonProperties[currentNext.name]
 	}
 
 	// if still we have no clue about where to stop, then right after the first previous might be good
 	if lastPossibleNextProp == nil {
-		log("--> last possible next prop not found, so getting the next of the first possible previous prop")
+		newProp.owner.log("--> last possible next prop not found, so getting the next of the first possible previous prop")
 		lastPossibleNextProp = firstPossiblePreviousProp.next
 	}
 
-	log("--> last next      = %s", lastPossibleNextProp)
+	newProp.owner.log("--> last next      = %s", lastPossibleNextProp)
 
 	// in any case, if the first previous was last, or touches the last next property,
 	// then we just have to squeeze our property in between !
 	if firstPossiblePreviousProp.touches(lastPossibleNextProp) {
-		log("--> 'first previous' and 'last next' already touch each other!")
+		newProp.owner.log("--> 'first previous' and 'last next' already touch each other!")
 		if lastPossibleNextProp == nil {
 			newProp.linkAfter(firstPossiblePreviousProp, true)
 			return
@@ -68,28 +68,28 @@ func (newProp *chainedProperty) link(originalProp *chainedProperty) {
 		return
 	}
 
-	log("--> no easy linking done here, so entering scanning loop")
+	newProp.owner.log("--> no easy linking done here, so entering scanning loop")
 
 	// so we basically look for the best previous property for our current prop,
 	// starting from the firstPossiblePreviousProp, and finishing at most with the lastPossibleNextProp
 	for previous := firstPossiblePreviousProp; !previous.touches(lastPossibleNextProp); previous = previous.next {
 
-		log("--> in the loop : can we sit betwen '%s' and '%s' ?", previous, previous.next)
+		newProp.owner.log("--> in the loop : can we sit betwen '%s' and '%s' ?", previous, previous.next)
 
 		// has the property right after the current previous property a better fit in the alphabetical sense ?
 		if next := previous.next; next.name > newProp.name {
 
-			log("--> in the loop : YEAH!")
+			newProp.owner.log("--> in the loop : YEAH!")
 
 			// yeah, so we stop right here
 			newProp.insertAfter(previous)
 			return
 		}
 
-		log("--> in the loop : nope!")
+		newProp.owner.log("--> in the loop : nope!")
 	}
 
-	log("--> exited the scanning loop : inserting just right before the last next")
+	newProp.owner.log("--> exited the scanning loop : inserting just right before the last next")
 
 	// we're here because we reached lastPossibleNextProp; so let's just insert our property before this
 	newProp.insertBefore(lastPossibleNextProp)
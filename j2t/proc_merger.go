@@ -3,47 +3,82 @@
 // all the maps resulting from unmarshalling all the JSON files
 //------------------------------------------------------------------------------
 
-package main
+package j2t
 
-import "fmt"
+import (
+	"reflect"
+)
 
-// digesting all the file maps, to build a common definition for them
-func merge(jsonMaps []*fileMap) *fileMap {
+// digesting all the file maps, to build a common definition for them; the config paths are prefixed with the given one
+func merge(jsonMaps []*fileMap, config *j2tConfig, configPrefix path, settings *mergeSettings) (*fileMap, error) {
 
-	commonDef := &fileMap{name: "Common definition", allChainedProperties: map[path]*chainedProperty{}}
+	// a seed, built from a schema, is digested first, so that its order & types prevail, but it's not a document
+	digestedMaps := jsonMaps
+	if settings.seed != nil {
+		digestedMaps = append([]*fileMap{settings.seed}, jsonMaps...)
+	}
+
+	// resolving the type conflicts first, as configured - with a seed, the types it declares are kept by default
+	conflicts, errConflicts := resolveConflicts(config, digestedMaps, configPrefix, settings.continueMode)
+	if errConflicts != nil {
+		return nil, errConflicts
+	}
+
+	commonDef := &fileMap{name: "Common definition", allChainedProperties: map[path]*chainedProperty{}, debugMode: settings.debugMode,
+		conflicts: conflicts}
 
 	// digesting each JSON
-	for _, jsonMap := range jsonMaps {
+	for _, jsonMap := range digestedMaps {
 
 		// some debugging log
-		// if debugMode {
+		// if settings.debugMode {
 		// 	jsonMap.displayOrdered(0, showValue)
 		// }
 
 		// digesting this JSON map into the common definition
-		commonDef.digest(jsonMap)
+		if errDigest := commonDef.digest(jsonMap); errDigest != nil {
+			return nil, errDigest
+		}
 
 		// some debugging log
-		// if debugMode {
+		// if settings.debugMode {
 		// 	commonDef.reorder()
 		// 	commonDef.displayOrdered(0, showKind)
 		// }
 	}
 
-	// reordering the common definition
+	// reordering the common definition - by majority vote, if asked to, unless the order comes from a seed
 	commonDef.reorder()
+	if settings.orderMode == orderModeVOTE && settings.seed == nil {
+		commonDef.voteOrder(jsonMaps)
+		commonDef.reorder()
+	}
 
-	// controlling that this JSON map is shown some respect, regarding the common definition
+	// controlling that this JSON map is shown some respect, regarding the common definition - unless it comes from a seed
+	violations := []*OrderError{}
 	for _, jsonMap := range jsonMaps {
-		commonDef.control(jsonMap)
+		if settings.seed != nil {
+			break
+		}
+		violations = append(violations, commonDef.control(jsonMap)...)
+		if len(violations) > 0 && settings.orderMode == orderModeSTRICT {
+			return nil, violations[0]
+		}
+	}
+	commonDef.violations = violations
+
+	// then, the configured column order wins over the discovered one
+	if len(config.ColumnOrder) > 0 {
+		commonDef.applyColumnOrder(config, configPrefix)
+		commonDef.reorder()
 	}
 
 	// retunring, for what's next, i.e. using this common definition to create tables
-	return commonDef
+	return commonDef, nil
 }
 
 // puts all the definition contained in the given file map, into the common definition
-func (commonDef *fileMap) digest(jsonMap *fileMap) *fileMap {
+func (commonDef *fileMap) digest(jsonMap *fileMap) error {
 
 	// initialisation is needed for the common definition, if it hasn't been done yet
 	if commonDef.chainedProperties == nil {
@@ -78,7 +113,10 @@ func (commonDef *fileMap) digest(jsonMap *fileMap) *fileMap {
 
 		// initialising the submaps recursively this way:
 		for propertyName, submap := range jsonMap.subMaps {
-			commonDef.subMaps[propertyName] = (&fileMap{parent: commonDef, name: propertyName}).digest(submap)
+			commonDef.subMaps[propertyName] = &fileMap{parent: commonDef, name: propertyName}
+			if errDigest := commonDef.subMaps[propertyName].digest(submap); errDigest != nil {
+				return errDigest
+			}
 		}
 
 	} else { // the definition has already been built once with one file map, so we have to add the missing info here
@@ -111,19 +149,20 @@ func (commonDef *fileMap) digest(jsonMap *fileMap) *fileMap {
 
 			} else {
 
-				// if the kind of the property from the definition, and the one from the file map differ,
-				// then we have a problem about a "common" definition
-				if existingProperty.kind != jsonMap.getPropertyKind(propertyName) {
-					msg := fmt.Sprintf("\n\nWe have a problem here with property: %s"+
-						"\ntype of '%s' is %s,"+
-						"\nbut type of '%s' is %s",
-						propertyName,
-						existingProperty.getPath(), existingProperty.kind,
-						jsonMap.chainedProperties[propertyName].getPath(), jsonMap.getPropertyKind(propertyName))
-					if continueMode {
-						println(msg)
-					} else {
-						err(msg)
+				// if the property has only been seen with null values so far, then it gets its kind from this file map
+				newKind := jsonMap.getPropertyKind(propertyName)
+				if existingProperty.kind == reflect.Invalid {
+					existingProperty.kind = newKind
+
+					// if the kind of the property from the definition, and the one from the file map differ,
+					// then we have a problem about a "common" definition - null values don't count here; this cannot happen
+					// once the conflicts are resolved, whatever the mode, so it's a bug if it does
+				} else if newKind != reflect.Invalid && existingProperty.kind != newKind {
+					return &TypeConflictError{
+						Path:      string(existingProperty.getPath()),
+						File:      jsonMap.root().name,
+						Type:      existingProperty.kind.String(),
+						FoundType: newKind.String(),
 					}
 				}
 
@@ -135,14 +174,15 @@ func (commonDef *fileMap) digest(jsonMap *fileMap) *fileMap {
 		// upgrading the submaps recursively this way:
 		for propertyName, submap := range jsonMap.subMaps {
 			if commonDef.subMaps[propertyName] == nil {
-				commonDef.subMaps[propertyName] = (&fileMap{parent: commonDef, name: propertyName}).digest(submap)
-			} else {
-				commonDef.subMaps[propertyName].digest(submap)
+				commonDef.subMaps[propertyName] = &fileMap{parent: commonDef, name: propertyName}
+			}
+			if errDigest := commonDef.subMaps[propertyName].digest(submap); errDigest != nil {
+				return errDigest
 			}
 		}
 	}
 
-	return commonDef
+	return nil
 }
 
 // building the array of ordered properties from the chained properties
@@ -166,8 +206,10 @@ func (commonDef *fileMap) reorder() {
 	}
 }
 
-// controlling that that the order of the properties is preserved in the common definition
-func (commonDef *fileMap) control(jsonMap *fileMap) {
+// controlling that that the order of the properties is preserved in the common definition; all the violations are returned
+func (commonDef *fileMap) control(jsonMap *fileMap) []*OrderError {
+
+	violations := []*OrderError{}
 
 	// going through the JSON map to check that the order between 2 consecutive items
 	// is maintained in the common definition
@@ -177,18 +219,22 @@ func (commonDef *fileMap) control(jsonMap *fileMap) {
 		if commonDef.propertyIndexes[propertyName] < commonDef.propertyIndexes[previousName] {
 			property := jsonMap.chainedProperties[propertyName]
 			previous := jsonMap.chainedProperties[previousName]
-			err("\nThere's a problem here: "+
-				"\nwe have '%s' before '%s'"+
-				"\nbut the order is inversed in the common definition!",
-				property.getPath(), previous.getPath(),
-			)
+			violations = append(violations, &OrderError{
+				Path:     string(property.getPath()),
+				Previous: string(previous.getPath()),
+				File:     jsonMap.root().name,
+			})
 		}
 	}
 
-	// dealing with the submaps
-	for _, submap := range jsonMap.subMaps {
-		commonDef.subMaps[submap.name].control(submap)
+	// dealing with the submaps, in order
+	for _, propertyName := range jsonMap.orderedProperties {
+		if submap := jsonMap.subMaps[propertyName]; submap != nil {
+			violations = append(violations, commonDef.subMaps[propertyName].control(submap)...)
+		}
 	}
+
+	return violations
 }
 
 // global-indexing each final properties, which will also correspond to a column number in the forecoming Excel file
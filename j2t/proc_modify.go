@@ -2,9 +2,7 @@
 // handling the modified columns for each JSON maps
 //------------------------------------------------------------------------------
 
-package main
-
-import "fmt"
+package j2t
 
 // handling value changes within each original json file
 func (commonDef *fileMap) handleModifiedColumns(config *j2tConfig, jsonMaps []*fileMap) error {
@@ -25,10 +23,10 @@ func (commonDef *fileMap) handleModifiedColumn(config *j2tConfig, jsonMap *fileM
 		// checking the existence of the columns mentioned here
 		propDef := commonDef.allChainedProperties[modifConfig.SetColumn]
 		if propDef == nil {
-			return fmt.Errorf("column '%s' does not exist", modifConfig.SetColumn)
+			return &ConfigPathError{Path: string(modifConfig.SetColumn), File: config.fileName, Column: string(modifConfig.SetColumn)}
 		}
 		if commonDef.allChainedProperties[modifConfig.When] == nil {
-			return fmt.Errorf("column '%s' does not exist", modifConfig.When)
+			return &ConfigPathError{Path: string(modifConfig.When), File: config.fileName, Column: string(modifConfig.SetColumn)}
 		}
 
 		// ok let's get the property to change, and the property serving as a condition
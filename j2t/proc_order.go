@@ -0,0 +1,256 @@
+//------------------------------------------------------------------------------
+// the code here deals with the properties not being in the same order in all
+// the JSON maps: either this stops the merging, or the order of the majority
+// is picked, and the conflicts are listed
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// how the ordering conflicts are dealt with
+type orderMode string
+
+const (
+	orderModeSTRICT orderMode = "strict" // the first ordering conflict stops the merging
+	orderModeVOTE   orderMode = "vote"   // the order of the majority is picked, and the conflicts are listed
+)
+
+// the wildcard standing for the properties of a section that are not listed in the configured column order, e.g. "address/*"
+const columnOrderWILDCARD = "*"
+
+// how to merge
+type mergeSettings struct {
+	continueMode bool      // if true, the type conflicts with no configured policy do not stop the merging
+	debugMode    bool      // if true, debug messages are printed out
+	orderMode    orderMode // how the ordering conflicts are dealt with
+	seed         *fileMap  // the map seeding the common definition, if any, built from a schema
+}
+
+// getting the settings to merge with; the ones given as options win over the configured ones
+func (config *j2tConfig) getMergeSettings(options Options) (*mergeSettings, error) {
+
+	settings := &mergeSettings{continueMode: options.Continue, debugMode: options.Debug, orderMode: orderMode(options.Order)}
+
+	if settings.orderMode == "" && config.General != nil {
+		settings.orderMode = config.General.Order
+	}
+	if settings.orderMode == "" {
+		settings.orderMode = orderModeSTRICT
+	}
+	if settings.orderMode != orderModeSTRICT && settings.orderMode != orderModeVOTE {
+		return nil, fmt.Errorf("invalid order mode: '%s'; possible values are: '%s', '%s'", settings.orderMode, orderModeSTRICT, orderModeVOTE)
+	}
+
+	return settings, nil
+}
+
+// reordering the properties of this common definition - and of its submaps - so that they agree as much as possible
+// with their order in the given JSON maps, i.e. with the order picked by the majority for each pair of properties
+func (commonDef *fileMap) voteOrder(jsonMaps []*fileMap) {
+
+	names := commonDef.orderedProperties
+	indexes := map[string]int{}
+	for i, name := range names {
+		indexes[name] = i
+	}
+
+	// counting the votes: votes[a*n+b] is the number of maps where a comes before b
+	n := len(names)
+	votes := make([]int, n*n)
+	for _, jsonMap := range jsonMaps {
+		for i, before := range jsonMap.orderedProperties {
+			for _, after := range jsonMap.orderedProperties[i+1:] {
+				votes[indexes[before]*n+indexes[after]]++
+			}
+		}
+	}
+
+	// starting from the current order, each property is moved to where it agrees with the most votes, until no move
+	// is worth it anymore; each move increases the overall agreement, so this does come to an end
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	for moved := true; moved; {
+		moved = false
+		for _, property := range append([]int{}, order...) {
+
+			// taking the property out
+			rest := []int{}
+			position := 0
+			for i, other := range order {
+				if other == property {
+					position = i
+				} else {
+					rest = append(rest, other)
+				}
+			}
+
+			// the agreement when inserting the property at each position, from the first one
+			score := 0
+			for _, other := range rest {
+				score += votes[property*n+other]
+			}
+			currentScore, bestScore, bestPosition := score, score, 0
+			for i, other := range rest {
+				score += votes[other*n+property] - votes[property*n+other]
+				if i+1 == position {
+					currentScore = score
+				}
+				if score > bestScore {
+					bestScore, bestPosition = score, i+1
+				}
+			}
+
+			// moving the property only if it's better
+			if bestScore > currentScore {
+				order = append(rest[:bestPosition], append([]int{property}, rest[bestPosition:]...)...)
+				moved = true
+			} else {
+				order = append(rest[:position], append([]int{property}, rest[position:]...)...)
+			}
+		}
+	}
+
+	// rechaining the properties in the voted order
+	votedNames := make([]string, n)
+	for i, index := range order {
+		votedNames[i] = names[index]
+	}
+	commonDef.relink(votedNames)
+
+	// voting for the submaps too
+	for propertyName, subMap := range commonDef.subMaps {
+		subJSONMaps := []*fileMap{}
+		for _, jsonMap := range jsonMaps {
+			if subJSONMap := jsonMap.subMaps[propertyName]; subJSONMap != nil {
+				subJSONMaps = append(subJSONMaps, subJSONMap)
+			}
+		}
+		subMap.voteOrder(subJSONMaps)
+	}
+}
+
+// ordering the properties of this common definition - and of its submaps - as configured; the properties not listed
+// are put where the wildcard of their section is, e.g. "address/*", or else appended, in their discovered order
+func (commonDef *fileMap) applyColumnOrder(config *j2tConfig, configPrefix path) {
+
+	if config.matchedColumnOrder == nil {
+		config.matchedColumnOrder = map[path]bool{}
+	}
+
+	mapPath := configPrefix + commonDef.getPath()
+	unlistedRank := len(config.ColumnOrder)
+
+	// the rank of each property: the position of the first entry for it, or for one of its nested properties
+	ranks := map[string]int{}
+	for i, entry := range config.ColumnOrder {
+		if entry == mapPath+columnOrderWILDCARD {
+			unlistedRank = i
+			config.matchedColumnOrder[entry] = true
+			continue
+		}
+		if !strings.HasPrefix(string(entry), string(mapPath)) {
+			continue
+		}
+		propertyName := strings.SplitN(string(entry[len(mapPath):]), "/", 2)[0]
+		if commonDef.chainedProperties[propertyName] == nil {
+			continue
+		}
+		if _, ranked := ranks[propertyName]; !ranked {
+			ranks[propertyName] = i
+		}
+		if entry == mapPath+path(propertyName) {
+			config.matchedColumnOrder[entry] = true // the nested entries are matched deeper
+		}
+	}
+
+	// sorting the properties accordingly, the unlisted ones keeping their order
+	rank := func(propertyName string) int {
+		if propertyRank, ranked := ranks[propertyName]; ranked {
+			return propertyRank
+		}
+		return unlistedRank
+	}
+	names := append([]string{}, commonDef.orderedProperties...)
+	sort.SliceStable(names, func(i, j int) bool {
+		return rank(names[i]) < rank(names[j])
+	})
+	commonDef.relink(names)
+
+	// ordering the submaps too
+	for _, subMap := range commonDef.subMaps {
+		subMap.applyColumnOrder(config, configPrefix)
+	}
+}
+
+// the entries of the configured column order that have matched no property at all - the new columns
+// being left out, since they're inserted later on
+func (config *j2tConfig) getUnknownColumnOrder() []string {
+
+	newColumns := map[path]bool{}
+	for _, newColumn := range config.NewColumns {
+		putAfter := string(newColumn.PutAfter)
+		newColumns[path(putAfter[:strings.LastIndex(putAfter, "/")+1]+newColumn.Name)] = true
+	}
+
+	unknown := []string{}
+	for _, entry := range config.ColumnOrder {
+		if !config.matchedColumnOrder[entry] && !newColumns[entry] {
+			unknown = append(unknown, string(entry))
+		}
+	}
+	if len(unknown) > 0 {
+		return []string{fmt.Sprintf("unknown path(s) in the column order of '%s': '%s'", config.fileName, strings.Join(unknown, "', '"))}
+	}
+
+	return nil
+}
+
+// rechaining the properties of this common definition in the given order
+func (commonDef *fileMap) relink(names []string) {
+	var previous *chainedProperty
+	for _, propertyName := range names {
+		property := commonDef.chainedProperties[propertyName]
+		property.previous, property.next = nil, nil
+		if previous != nil {
+			property.linkAfter(previous, false)
+		}
+		previous = property
+	}
+}
+
+// listing the ordering conflicts, i.e. the pairs of properties, with the files where they're not in the common order
+func getOrderSummary(violations []*OrderError) []string {
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	// grouping the files by pair of properties
+	type pair struct{ previous, path string }
+	pairs := []pair{}
+	files := map[pair][]string{}
+	for _, violation := range violations {
+		key := pair{violation.Previous, violation.Path}
+		if files[key] == nil {
+			pairs = append(pairs, key)
+		}
+		files[key] = append(files[key], violation.File)
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return len(files[pairs[i]]) > len(files[pairs[j]])
+	})
+
+	summary := []string{fmt.Sprintf("%d ordering conflict(s) found, the order of the majority being kept:", len(pairs))}
+	for _, key := range pairs {
+		summary = append(summary, fmt.Sprintf("- '%s' before '%s' in %s", key.previous, key.path, listFiles(files[key])))
+	}
+
+	return summary
+}
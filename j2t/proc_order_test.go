@@ -0,0 +1,111 @@
+//------------------------------------------------------------------------------
+// testing how the order of the properties is picked by majority vote, and how
+// the documents not following it are listed
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the JSON maps for the given documents, named after their index
+func getTestMaps(t *testing.T, documents []string) []*fileMap {
+	jsonMaps := []*fileMap{}
+	for i, document := range documents {
+		jsonMap := &fileMap{name: string(rune('a' + i))}
+		if errUnmarshal := json.Unmarshal([]byte(document), jsonMap); errUnmarshal != nil {
+			t.Fatalf("could not read document %d: %v", i, errUnmarshal)
+		}
+		jsonMaps = append(jsonMaps, jsonMap)
+	}
+	return jsonMaps
+}
+
+// the paths of a common definition's columns, in order
+func getColumnPaths(commonDef *fileMap) []string {
+	paths := []string{}
+	for _, property := range commonDef.orderedProperties {
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+			paths = append(paths, getColumnPaths(subMap)...)
+		} else {
+			paths = append(paths, string(commonDef.chainedProperties[property].getPath()))
+		}
+	}
+	return paths
+}
+
+func TestVoteOrder(t *testing.T) {
+
+	cases := []struct {
+		name       string
+		documents  []string
+		order      []string // the expected order of the columns
+		violations []string // the expected violations, as "file: previous < path"
+	}{
+		{
+			name: "no conflict",
+			documents: []string{
+				`{"id": 1, "name": "alpha", "price": 2}`,
+				`{"id": 2, "price": 3}`,
+			},
+			order: []string{"id", "name", "price"},
+		},
+		{
+			name: "the majority wins, whoever comes first",
+			documents: []string{
+				`{"price": 1, "id": 1, "name": "alpha"}`,
+				`{"id": 2, "name": "beta", "price": 2}`,
+				`{"id": 3, "name": "gamma", "price": 3}`,
+			},
+			order:      []string{"id", "name", "price"},
+			violations: []string{"a: price < id"},
+		},
+		{
+			name: "the properties seen in some documents only count for those",
+			documents: []string{
+				`{"id": 1, "name": "alpha"}`,
+				`{"name": "beta", "id": 2, "price": 2}`,
+				`{"name": "gamma", "price": 3}`,
+				`{"name": "delta", "id": 4}`,
+			},
+			order:      []string{"name", "id", "price"},
+			violations: []string{"a: id < name"},
+		},
+		{
+			name: "the sections are voted on separately",
+			documents: []string{
+				`{"id": 1, "address": {"zip": "75001", "city": "Paris"}}`,
+				`{"id": 2, "address": {"city": "Lyon", "zip": "69001"}}`,
+				`{"address": {"city": "Nice", "zip": "06000"}, "id": 3}`,
+			},
+			order:      []string{"id", "address/city", "address/zip"},
+			violations: []string{"a: address/zip < address/city", "c: address < id"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			commonDef, errMerge := merge(getTestMaps(t, testCase.documents), &j2tConfig{}, "", &mergeSettings{orderMode: orderModeVOTE})
+			if errMerge != nil {
+				t.Fatalf("could not merge: %v", errMerge)
+			}
+
+			if order := getColumnPaths(commonDef); !reflect.DeepEqual(order, testCase.order) {
+				t.Errorf("expected the order %s, got %s", strings.Join(testCase.order, ", "), strings.Join(order, ", "))
+			}
+
+			violations := []string{}
+			for _, violation := range commonDef.violations {
+				violations = append(violations, violation.File+": "+violation.Previous+" < "+violation.Path)
+			}
+			if len(violations) != len(testCase.violations) || len(violations) > 0 && !reflect.DeepEqual(violations, testCase.violations) {
+				t.Errorf("expected the violations %v, got %v", testCase.violations, violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+//------------------------------------------------------------------------------
+// the code here is responsible for parsing all the JSON files, and
+// building the common structure for them
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	fspath "path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	folderProperty = "_folder" // the synthetic property giving the sub-folder of each file, when some are not at the root
+)
+
+// by default, the JSON - and JSON Lines - files at the root of the folder are scanned, compressed or not, or within archives
+var defaultIncludePatterns = []string{"*.json", "*.jsonl", "*.ndjson", "*.json.gz", "*.jsonl.gz", "*.ndjson.gz", "*.zip", "*.tar.gz", "*.tgz"}
+
+// the extensions of the JSON files, which are the ones read within the archives
+var jsonExtensions = []string{".json", ".jsonl", ".ndjson"}
+
+// the extensions of the JSON Lines files, where each line is a JSON document, and not the whole file
+var jsonLinesExtensions = []string{".jsonl", ".ndjson"}
+
+// how to scan: the patterns, matched against the files' relative paths, telling which files to scan, and where the rows are
+type scanSettings struct {
+	include  []string
+	exclude  []string
+	rowsPath path // the path of the array giving the rows, within each JSON document, if not the document itself
+	jobs     int  // how many files can be parsed at the same time
+}
+
+// getting the settings to scan with; the ones given as options win over the configured ones
+func (config *j2tConfig) getScanSettings(options Options) (*scanSettings, error) {
+
+	settings := &scanSettings{include: options.Include, exclude: options.Exclude, rowsPath: path(options.RowsPath)}
+
+	if len(settings.include) == 0 && config.General != nil {
+		settings.include = config.General.Include
+	}
+	if len(settings.include) == 0 {
+		settings.include = defaultIncludePatterns
+	}
+	if len(settings.exclude) == 0 && config.General != nil {
+		settings.exclude = config.General.Exclude
+	}
+	if settings.rowsPath == "" && config.General != nil {
+		settings.rowsPath = config.General.RowsPath
+	}
+	settings.rowsPath = path(strings.Trim(string(settings.rowsPath), "/"))
+
+	// by default, as many files as CPUs are parsed at the same time
+	settings.jobs = options.Jobs
+	if settings.jobs <= 0 {
+		settings.jobs = runtime.NumCPU()
+	}
+
+	// checking the patterns right away
+	for _, pattern := range append(append([]string{}, settings.include...), settings.exclude...) {
+		for _, part := range strings.Split(pattern, "/") {
+			if _, errPattern := fspath.Match(part, ""); errPattern != nil {
+				return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, errPattern)
+			}
+		}
+	}
+
+	return settings, nil
+}
+
+// does the given file, or folder, match one of the given patterns ?
+func matchesOne(patterns []string, filePath string, prefix bool) bool {
+	for _, pattern := range patterns {
+		if matchSegments(strings.Split(pattern, "/"), strings.Split(filePath, "/"), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matching the parts of a path with the parts of a glob pattern, where "**" matches any number of folders;
+// in prefix mode, the path is a folder, and we're checking whether the files within it can match
+func matchSegments(patternParts []string, pathParts []string, prefix bool) bool {
+	for len(patternParts) > 0 {
+		if patternParts[0] == "**" {
+			for i := 0; i <= len(pathParts); i++ {
+				if matchSegments(patternParts[1:], pathParts[i:], prefix) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(pathParts) == 0 {
+			return prefix
+		}
+		if matched, _ := fspath.Match(patternParts[0], pathParts[0]); !matched {
+			return false
+		}
+		patternParts, pathParts = patternParts[1:], pathParts[1:]
+	}
+	return len(pathParts) == 0
+}
+
+// the JSON documents scanned from 1 file - or archive member - with its path
+type scannedDocuments struct {
+	documentPath string
+	fileMaps     []*fileMap
+}
+
+// main directory scanning function, over the given file system, with the files matching the given settings' patterns;
+// the files are parsed concurrently, while the results keep the order of the files
+func scanDir(fsys fs.FS, configFileName string, settings *scanSettings) (results []*fileMap, err error) {
+
+	// listing the files to scan
+	filePaths := []string{}
+	errWalk := fs.WalkDir(fsys, ".", func(filePath string, dirEntry fs.DirEntry, errEntry error) error {
+
+		if errEntry != nil {
+			return errEntry
+		}
+
+		// a folder: going into it, unless it's excluded, or no file can be included within it
+		if dirEntry.IsDir() {
+			if filePath != "." && (matchesOne(settings.exclude, filePath, false) || !matchesOne(settings.include, filePath, true)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		// a file: scanning it if included, and not excluded
+		if filePath != configFileName && matchesOne(settings.include, filePath, false) && !matchesOne(settings.exclude, filePath, false) {
+			filePaths = append(filePaths, filePath)
+		}
+
+		return nil
+	})
+	if errWalk != nil {
+		return nil, fmt.Errorf("error while scanning the directory: %w", errWalk)
+	}
+
+	// scanning the files with a pool of workers, each file's results being kept at the file's index
+	scanned := make([][]*scannedDocuments, len(filePaths))
+	scanErrors := make([]error, len(filePaths))
+	indexes := make(chan int)
+	waitGroup := sync.WaitGroup{}
+	for worker := 0; worker < settings.jobs; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indexes {
+				scanErrors[index] = scanFile(fsys, filePaths[index], settings.rowsPath, func(documentPath string, fileMaps []*fileMap) {
+					scanned[index] = append(scanned[index], &scannedDocuments{documentPath: documentPath, fileMaps: fileMaps})
+				})
+			}
+		}()
+	}
+	for index := range filePaths {
+		indexes <- index
+	}
+	close(indexes)
+	waitGroup.Wait()
+
+	// the sub-folder of each file
+	folders := map[*fileMap]string{}
+	inSubFolders := false
+
+	// gathering the results, in the files' order
+	for index, filePath := range filePaths {
+
+		if scanErrors[index] != nil {
+			return nil, fmt.Errorf("error while treating file: %s. Cause: %w", filePath, scanErrors[index])
+		}
+
+		for _, documents := range scanned[index] {
+			results = append(results, documents.fileMaps...)
+
+			// keeping track of the sub-folder - an archive being seen as a folder
+			if folder := fspath.Dir(documents.documentPath); folder != "." {
+				for _, fileMap := range documents.fileMaps {
+					folders[fileMap] = folder
+				}
+				inSubFolders = true
+			}
+		}
+	}
+
+	// if the files come from different folders, then this has to be seen
+	if inSubFolders {
+		for _, fileMap := range results {
+			fileMap.addFolderProperty(folders[fileMap])
+		}
+	}
+
+	return
+}
+
+// main file scanning function; the file can be compressed, or be an archive, so the JSON documents found are
+// given to the collect function, along with the path of the file - or archive member - they come from
+func scanFile(fsys fs.FS, fileName string, rowsPath path, collect func(documentPath string, fileMaps []*fileMap)) error {
+
+	// opening the file
+	file, errOpen := fsys.Open(fileName)
+	if errOpen != nil {
+		return fmt.Errorf("error while opening the file at path: %s. Cause: %s", fileName, errOpen)
+	}
+	defer file.Close()
+
+	switch {
+	case strings.HasSuffix(fileName, ".zip"):
+		return scanZip(fileName, file, rowsPath, collect)
+	case strings.HasSuffix(fileName, ".tar.gz"), strings.HasSuffix(fileName, ".tgz"):
+		return scanTarGz(fileName, file, rowsPath, collect)
+	case strings.HasSuffix(fileName, ".gz"):
+		return scanGz(fileName, file, rowsPath, collect)
+	}
+
+	return scanDocuments(fileName, file, rowsPath, collect)
+}
+
+// scanning the JSON documents of 1 file - or archive member - named after its path, without its extension
+func scanDocuments(documentPath string, reader io.Reader, rowsPath path, collect func(documentPath string, fileMaps []*fileMap)) error {
+	fileMaps, errScan := scanReader(documentPath, strings.TrimSuffix(documentPath, fspath.Ext(documentPath)), reader, rowsPath)
+	if errScan != nil {
+		return errScan
+	}
+	collect(documentPath, fileMaps)
+	return nil
+}
+
+// is the given file - or archive member - a JSON one ?
+func isJSON(name string) bool {
+	for _, extension := range jsonExtensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// is the given file - or stream - name the one of a JSON Lines file ?
+func isJSONLines(name string) bool {
+	for _, extension := range jsonLinesExtensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// adding a synthetic property to this root map, as the first one, giving the sub-folder it's been read from
+func (rootMap *fileMap) addFolderProperty(folder string) {
+	rootMap.orderedProperties = append([]string{folderProperty}, rootMap.orderedProperties...)
+	rootMap.values[folderProperty] = folder
+	rootMap.chain()
+}
+
+// a JSON document read from a stream, not unmarshalled yet
+type streamDocument struct {
+	name    string
+	content json.RawMessage
+}
+
+// scanning the JSON documents given by a reader, i.e. 1 document, or a stream of documents - e.g. JSON Lines - in which case
+// each document is named after the stream, and the line it starts at; a single document, if not from a JSON Lines stream,
+// is given the single name. Each document gives 1 row, or 1 row per item if it's an array - or if the configured rows
+// path leads to an array within it - in which case each row is named after the document, and the item's index
+func scanReader(streamName string, singleName string, reader io.Reader, rowsPath path) ([]*fileMap, error) {
+
+	// reading the content
+	streamBytes, errRead := ioutil.ReadAll(reader)
+	if errRead != nil {
+		return nil, fmt.Errorf("error while reading '%s'. Cause: %s", streamName, errRead)
+	}
+
+	// splitting the stream into JSON documents
+	documents := []*streamDocument{}
+	decoder := json.NewDecoder(bytes.NewReader(streamBytes))
+	line, lineStart, previousLine, sameLineCount := 1, 0, 0, 0
+	for {
+
+		// finding the line where the next document starts
+		start := int(decoder.InputOffset())
+		for start < len(streamBytes) && strings.ContainsRune(" \t\r\n", rune(streamBytes[start])) {
+			start++
+		}
+		line += bytes.Count(streamBytes[lineStart:start], []byte("\n"))
+		lineStart = start
+
+		// reading the document
+		document := &streamDocument{name: fmt.Sprintf("%s#line%d", streamName, line)}
+		if errDecode := decoder.Decode(&document.content); errDecode == io.EOF {
+			break
+		} else if errDecode != nil {
+			return nil, fmt.Errorf("error while parsing '%s' at line %d. Cause: %s", streamName, line, errDecode)
+		}
+
+		// several documents on the same line
+		if line == previousLine {
+			sameLineCount++
+			document.name = fmt.Sprintf("%s-%d", document.name, sameLineCount+1)
+		} else {
+			previousLine, sameLineCount = line, 0
+		}
+
+		documents = append(documents, document)
+	}
+
+	// the usual case: 1 file, 1 document
+	source := streamName
+	if len(documents) == 1 && !isJSONLines(streamName) {
+		documents[0].name = singleName
+		source = ""
+	}
+
+	// unmarshalling each JSON document into rows
+	rootMaps := []*fileMap{}
+	for _, document := range documents {
+		rows, errRows := document.getRows(rowsPath)
+		if errRows != nil {
+			return nil, fmt.Errorf("error while parsing '%s'. Cause: %w", document.name, errRows)
+		}
+		if source != "" {
+			for _, row := range rows {
+				row.source = source // the rows keep the order of the stream
+			}
+		}
+		rootMaps = append(rootMaps, rows...)
+	}
+
+	// we're fine
+	return rootMaps, nil
+}
+
+// unmarshalling the given JSON document into 1 row, or 1 row per item if the rows are given by an array,
+// either the document itself, or the one found at the given rows path - which must be there, and not null
+func (document *streamDocument) getRows(rowsPath path) ([]*fileMap, error) {
+
+	content := document.content
+
+	// going down to the rows, if configured so
+	if rowsPath != "" {
+		for _, key := range strings.Split(string(rowsPath), "/") {
+			wrapper := map[string]json.RawMessage{}
+			if errWrapper := json.Unmarshal(content, &wrapper); errWrapper != nil {
+				return nil, fmt.Errorf("could not find the rows at path '%s': %s", rowsPath, errWrapper)
+			}
+			found := false
+			if content, found = wrapper[key]; !found || firstByte(content) == 'n' {
+				return nil, &RowsPathError{Path: string(rowsPath), Key: key, File: document.name, Null: found}
+			}
+		}
+	}
+
+	// 1 row per item
+	if firstByte(content) == '[' {
+		items := []json.RawMessage{}
+		if errItems := json.Unmarshal(content, &items); errItems != nil {
+			return nil, errItems
+		}
+		rows := []*fileMap{}
+		for i, item := range items {
+			row := &fileMap{name: fmt.Sprintf("%s#%d", document.name, i), source: document.name}
+			if firstByte(item) != '{' {
+				return nil, fmt.Errorf("item %d is not a JSON object", i)
+			}
+			if errUnmarshall := json.Unmarshal(item, row); errUnmarshall != nil {
+				return nil, fmt.Errorf("item %d: %w", i, errUnmarshall)
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	// 1 row only
+	row := &fileMap{name: document.name}
+	if firstByte(content) != '{' {
+		return nil, fmt.Errorf("not a JSON object, nor an array of objects")
+	}
+	if errUnmarshall := json.Unmarshal(content, row); errUnmarshall != nil {
+		return nil, errUnmarshall
+	}
+	return []*fileMap{row}, nil
+}
+
+// the first meaningful byte of a JSON value
+func firstByte(content json.RawMessage) byte {
+	if trimmed := bytes.TrimLeft(content, " \t\r\n"); len(trimmed) > 0 {
+		return trimmed[0]
+	}
+	return 0
+}
@@ -0,0 +1,275 @@
+//------------------------------------------------------------------------------
+// the code here exports the common definition as a JSON Schema, describing
+// the scanned JSON documents, with the types, optionality & categories found
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// the version of the JSON Schemas written out
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// the properties added to the documents, which are not described in the schema
+var syntheticProperties = map[string]bool{
+	folderProperty: true, childParentProperty: true, childIndexProperty: true, statusProperty: true, errorsProperty: true,
+}
+
+// a JSON Schema, or a part of it
+type jsonSchema struct {
+	Schema     string            `json:"$schema,omitempty"`
+	Title      string            `json:"title,omitempty"`
+	Type       interface{}       `json:"type,omitempty"` // 1 type, or several, e.g. ["string", "null"]
+	Properties *schemaProperties `json:"properties,omitempty"`
+	Required   []string          `json:"required,omitempty"`
+	Items      *jsonSchema       `json:"items,omitempty"`
+	Enum       []interface{}     `json:"enum,omitempty"`
+}
+
+// the properties of an object's schema, kept in the columns' order
+type schemaProperties struct {
+	names   []string
+	schemas map[string]*jsonSchema
+}
+
+// MarshalJSON : keeping the properties' order
+func (thisProps *schemaProperties) MarshalJSON() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("{")
+	for i, name := range thisProps.names {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		nameBytes, errName := json.Marshal(name)
+		if errName != nil {
+			return nil, errName
+		}
+		schemaBytes, errSchema := json.Marshal(thisProps.schemas[name])
+		if errSchema != nil {
+			return nil, errSchema
+		}
+		buffer.Write(nameBytes)
+		buffer.WriteString(":")
+		buffer.Write(schemaBytes)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// what's needed to describe the arrays, which have been turned into values, submaps or child sheets; and the properties
+// which types conflicted, which have been turned into 1 type, or split
+type schemaContext struct {
+	strategies  map[path]arrayStrategy // the strategy used for each array, by config path
+	childSheets map[path]*childSheet   // the child sheets, by array path
+	conflicts   map[path]*typeConflict // the resolved type conflicts, by config path of the resulting properties
+}
+
+// a new schema context, for the given common definition
+func newSchemaContext(commonDef *fileMap, strategies map[path]arrayStrategy) *schemaContext {
+
+	context := &schemaContext{strategies: strategies, childSheets: map[path]*childSheet{}, conflicts: map[path]*typeConflict{}}
+	context.addConflicts(commonDef.conflicts)
+	for _, sheet := range commonDef.childSheets {
+		context.childSheets[sheet.path] = sheet
+		context.addConflicts(sheet.commonDef.conflicts)
+	}
+
+	return context
+}
+
+// indexing the given conflicts by the config paths of the properties resulting from their resolution
+func (context *schemaContext) addConflicts(conflicts []*typeConflict) {
+	for _, conflict := range conflicts {
+		prefix := conflict.path[:len(conflict.path)-len(conflict.getName())]
+		for _, name := range conflict.getResolvedNames() {
+			context.conflicts[prefix+path(name)] = conflict
+		}
+	}
+}
+
+// the schema of the objects described by this common definition, given the JSON maps it's been built from - at the same
+// level; a property is required if all these maps have it, and can be null if any of them has it null
+func (commonDef *fileMap) getSchema(context *schemaContext, jsonMaps []*fileMap) (*jsonSchema, error) {
+
+	schema := &jsonSchema{Type: "object", Properties: &schemaProperties{schemas: map[string]*jsonSchema{}}}
+
+	for _, propertyName := range commonDef.orderedProperties {
+
+		prop := commonDef.chainedProperties[propertyName]
+		if prop.computed || commonDef.parent == nil && syntheticProperties[propertyName] {
+			continue
+		}
+
+		// a property which types conflicted is described once, under its original name, with all the types found
+		if conflict := context.conflicts[prop.getConfigPath()]; conflict != nil {
+			if schema.Properties.schemas[conflict.getName()] == nil {
+				schema.addConflictSchema(conflict, jsonMaps)
+			}
+			continue
+		}
+
+		// how many maps have this property, and how many have it null; and the submaps, if any
+		present, nulls := 0, 0
+		subJSONMaps := []*fileMap{}
+		for _, jsonMap := range jsonMaps {
+			if jsonMap.chainedProperties[propertyName] == nil {
+				continue
+			}
+			present++
+			if subJSONMap := jsonMap.subMaps[propertyName]; subJSONMap != nil {
+				subJSONMaps = append(subJSONMaps, subJSONMap)
+			} else if jsonMap.values[propertyName] == nil {
+				nulls++
+			}
+		}
+
+		// the property's own schema
+		propSchema, errSchema := commonDef.getPropertySchema(context, prop, subJSONMaps)
+		if errSchema != nil {
+			return nil, errSchema
+		}
+		if nulls > 0 && propSchema.Type != "null" {
+			propSchema.Type = []interface{}{propSchema.Type, "null"}
+			if propSchema.Enum != nil {
+				propSchema.Enum = append(propSchema.Enum, nil)
+			}
+		}
+
+		schema.Properties.names = append(schema.Properties.names, propertyName)
+		schema.Properties.schemas[propertyName] = propSchema
+		if len(jsonMaps) > 0 && present == len(jsonMaps) {
+			schema.Required = append(schema.Required, propertyName)
+		}
+	}
+
+	return schema, nil
+}
+
+// describing a property which types conflicted, with the types found in the files before resolving the conflict, given
+// the JSON maps at the same level; the property is required if all these maps have it, under any of its resolved names
+func (schema *jsonSchema) addConflictSchema(conflict *typeConflict, jsonMaps []*fileMap) {
+
+	types := []interface{}{}
+	for _, kind := range conflict.kinds {
+		if len(conflict.files[kind]) > 0 {
+			types = append(types, schemaKindType(kind))
+		}
+	}
+	if conflict.nulls {
+		types = append(types, "null")
+	}
+
+	present := 0
+	for _, jsonMap := range jsonMaps {
+		for _, name := range conflict.getResolvedNames() {
+			if jsonMap.chainedProperties[name] != nil {
+				present++
+				break
+			}
+		}
+	}
+
+	schema.Properties.names = append(schema.Properties.names, conflict.getName())
+	schema.Properties.schemas[conflict.getName()] = &jsonSchema{Type: types}
+	if len(jsonMaps) > 0 && present == len(jsonMaps) {
+		schema.Required = append(schema.Required, conflict.getName())
+	}
+}
+
+// the JSON Schema type for the given kind of value
+func schemaKindType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Map:
+		return "object"
+	}
+	return "null"
+}
+
+// the schema of 1 property, given the submaps for this property, if it's an object
+func (commonDef *fileMap) getPropertySchema(context *schemaContext, prop *chainedProperty, subJSONMaps []*fileMap) (*jsonSchema, error) {
+
+	subMap := commonDef.subMaps[prop.name]
+
+	// an array, turned into something else
+	if strategy, isArray := context.strategies[prop.getConfigPath()]; isArray {
+		arraySchema := &jsonSchema{Type: "array"}
+		switch strategy {
+
+		// the items have their own sheet, and thus their own common definition
+		case arrayStrategySHEET:
+			if sheet := context.childSheets[prop.getConfigPath()]; sheet != nil {
+				itemSchema, errSchema := sheet.commonDef.getSchema(context, sheet.jsonMaps)
+				if errSchema != nil {
+					return nil, errSchema
+				}
+				arraySchema.Items = itemSchema
+			}
+
+		// the items are spread over several columns, the first one being representative
+		case arrayStrategyCOLUMNS:
+			if subMap != nil && subMap.chainedProperties["0"] != nil {
+				itemMaps := []*fileMap{}
+				for _, subJSONMap := range subJSONMaps {
+					if itemMap := subJSONMap.subMaps["0"]; itemMap != nil {
+						itemMaps = append(itemMaps, itemMap)
+					}
+				}
+				if subMap.subMaps["0"] == nil {
+					itemMaps = nil
+				}
+				itemSchema, errSchema := subMap.getPropertySchema(context, subMap.chainedProperties["0"], itemMaps)
+				if errSchema != nil {
+					return nil, errSchema
+				}
+				arraySchema.Items = itemSchema
+			}
+		}
+		return arraySchema, nil
+	}
+
+	// an object
+	if subMap != nil {
+		return subMap.getSchema(context, subJSONMaps)
+	}
+
+	// a simple value, which type may be refined with its stats
+	if errDetect := prop.detectStat(); errDetect != nil {
+		return nil, errDetect
+	}
+	switch prop.kind {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Float64:
+		if prop.statistic.decimal {
+			return &jsonSchema{Type: "number"}, nil
+		}
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.String:
+		propSchema := &jsonSchema{Type: "string"}
+		if prop.statistic.kind == statKindCATEGORY {
+			values := []string{}
+			for value := range prop.statistic.valueCounts {
+				values = append(values, value)
+			}
+			sort.Strings(values)
+			for _, value := range values {
+				propSchema.Enum = append(propSchema.Enum, value)
+			}
+		}
+		return propSchema, nil
+	}
+
+	// null values only
+	return &jsonSchema{Type: "null"}, nil
+}
@@ -0,0 +1,566 @@
+//------------------------------------------------------------------------------
+// putting some stats into the Excel file
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	excel "github.com/360EntSecGroup-Skylar/excelize"
+)
+
+// incrementing the stat for a given value
+func (thisStat *stat) countUp(value interface{}) *stat {
+
+	// null values are counted apart
+	if value == nil {
+		thisStat.nullCount++
+		return thisStat
+	}
+
+	// seeing the given value as a string
+	key := fmt.Sprintf("%v", value)
+
+	// counting up how many times we've seen it
+	thisStat.valueCounts[key] = thisStat.valueCounts[key] + 1
+
+	// updating the property's max length if needed
+	if newLength := len(key); newLength > thisStat.owner.maxLength {
+		thisStat.owner.maxLength = newLength
+	}
+
+	// maybe we can determine the type right away, if not done yet
+	if thisStat.kind == "" {
+		switch value.(type) {
+		case bool:
+			thisStat.kind = statKindBOOLEAN
+		case float64, json.Number:
+			thisStat.kind = statKindNUMBER
+		case string:
+			thisStat.kind = statKindTEXT
+		}
+	}
+
+	return thisStat
+}
+
+// initialising a stat for a property
+func (thisProp *chainedProperty) initStat(jsonMap *fileMap) {
+
+	// initialising the stat
+	thisProp.statistic = &stat{
+		owner:       thisProp,
+		valueCounts: map[string]int{},
+	}
+
+	// taking the given map's value into account
+	thisProp.updateStat(jsonMap)
+}
+
+// updating a stat for a property, if it has a value - i.e. it's not a submap - in the given map; a seed does not count
+func (thisProp *chainedProperty) updateStat(jsonMap *fileMap) {
+	if jsonMap.root().seed {
+		return
+	}
+	if value, hasValue := jsonMap.values[thisProp.name]; hasValue {
+		thisProp.statistic.countUp(value)
+	}
+}
+
+// where the stats are written in the Excel file
+type statsPlace string
+
+const (
+	statsPlaceBELOW statsPlace = "below" // 2 lines below the data, on each sheet
+	statsPlaceSHEET statsPlace = "sheet" // on a dedicated sheet, so that the data sheets only hold the headers & data
+)
+
+// the name of the dedicated sheet for the stats
+const statsSheetName = "stats"
+
+// the widths of the columns of the stats sheet: the labels, the values, and the percentages
+var statsSheetWidths = []float64{40, 14, 10}
+
+// where to write the stats of a sheet
+type statsTarget struct {
+	sheet      string // the sheet to write into
+	line       int    // the line of the stats' headers - or, with 1 block per property, of the next block's header
+	lastLine   int    // the last line written so far
+	dataPrefix string // what refers to the data sheet in the formulas, if the stats are on another sheet, e.g. "'results'!"
+	byProperty bool   // if true, 1 block of lines per property, with each stat on 1 line; otherwise, 1 column per property, under its data
+}
+
+// the cell of a part of a stat - e.g. the label, the count, or the percentage of a category value - given the first line
+// of the stats, the index of the stat, and its number of parts: under the property's data column, each part on its line,
+// or, with 1 block per property, each stat on its line, with its parts side by side
+func (target *statsTarget) statCell(prop *chainedProperty, statLine, index, parts, part int) (row int, col int) {
+	if target.byProperty {
+		row, col = statLine+index, 1+part
+	} else {
+		row, col = statLine+parts*index+part, prop.index
+	}
+	if row > target.lastLine {
+		target.lastLine = row
+	}
+	return row, col
+}
+
+// where to write the stats; the place given as an option wins over the configured one
+func (config *j2tConfig) getStatsPlace(option string) (statsPlace, error) {
+
+	place := statsPlace(option)
+	if place == "" && config.General != nil {
+		place = config.General.Stats
+	}
+	if place == "" {
+		place = statsPlaceBELOW
+	}
+	if place != statsPlaceBELOW && place != statsPlaceSHEET {
+		return "", fmt.Errorf("invalid stats place: '%s'; possible values are: '%s', '%s'", place, statsPlaceBELOW, statsPlaceSHEET)
+	}
+
+	return place, nil
+}
+
+// writing the stats of all the given sheets on a dedicated sheet: under the name of each data sheet, 1 block of lines
+// per property, with its header, stat kind and stats - the formulas referring to the data sheet
+func writeStatsSheet(excelFile *workbook, conf *j2tConfig, sheets []*childSheet) error {
+
+	// the sheet name has to be unique
+	sheetName := statsSheetName
+	for excelFile.GetSheetIndex(sheetName) != 0 {
+		sheetName = "_" + sheetName
+	}
+	excelFile.NewSheet(sheetName)
+
+	line := 1
+	for _, sheet := range sheets {
+
+		// nothing to say about a sheet without columns
+		if len(sheet.commonDef.orderedProperties) == 0 {
+			continue
+		}
+		dataSheet := sheet.commonDef.sheet()
+
+		// the name of the data sheet, over its blocks
+		if errSet := setString(excelFile, sheetName, line, 1, dataSheet); errSet != nil {
+			return &WriteError{Err: errSet}
+		}
+		if errMerge := mergeCells(excelFile, sheetName, line, 1, line, len(statsSheetWidths)); errMerge != nil {
+			return &WriteError{Err: errMerge}
+		}
+		style, errStyle := excelFile.getStyle(`{"font":{"bold":true, "size":14}, "alignment":{"horizontal":"center"}}`)
+		if errStyle != nil {
+			return &WriteError{Err: errStyle}
+		}
+		if errSet := setStyle(excelFile, sheetName, line, 1, style); errSet != nil {
+			return &WriteError{Err: errSet}
+		}
+
+		// the stats for each property
+		target := &statsTarget{
+			sheet:      sheetName,
+			line:       line + 2,
+			dataPrefix: "'" + strings.ReplaceAll(dataSheet, "'", "''") + "'!",
+			byProperty: true,
+		}
+		if errStats := sheet.commonDef.writeStats(excelFile, conf, sheet.commonDef.getHeaderLine(conf), len(sheet.jsonMaps), target); errStats != nil {
+			return errStats
+		}
+
+		// the next data sheet, after an empty line
+		line = target.line + 1
+	}
+
+	for i, width := range statsSheetWidths {
+		column, errCol := excel.ColumnNumberToName(i + 1)
+		if errCol != nil {
+			return &WriteError{Err: errCol}
+		}
+		if errWidth := excelFile.SetColWidth(sheetName, column, column, width); errWidth != nil {
+			return &WriteError{Err: errWidth}
+		}
+	}
+
+	return nil
+}
+
+// writing all the stats, for the data found below the given header line
+func (commonDef *fileMap) writeStats(excelFile *workbook, conf *j2tConfig, headerLine int, nbRows int, target *statsTarget) error {
+
+	for _, property := range commonDef.orderedProperties {
+
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+
+			// going under
+			if errWrite := subMap.writeStats(excelFile, conf, headerLine, nbRows, target); errWrite != nil {
+				return errWrite
+			}
+		} else {
+
+			prop := commonDef.chainedProperties[property]
+
+			// first we need to know what kind of stat we have
+			if errDetect := prop.detectStat(); errDetect != nil {
+				return &WriteError{Path: string(prop.getPath()), Err: errDetect}
+			}
+
+			// let's write the stat now
+			if errWrite := prop.writeStat(excelFile, conf, headerLine, nbRows, target); errWrite != nil {
+				return &WriteError{Path: string(prop.getPath()), Err: errWrite}
+			}
+
+			// with 1 block per property, the next one comes after an empty line
+			if target.byProperty {
+				target.line = target.lastLine + 2
+			}
+		}
+	}
+
+	return nil
+}
+
+// detecting the stat kind
+func (thisProp *chainedProperty) detectStat() error {
+
+	// a property with null values only is seen as text
+	if thisProp.statistic.kind == "" {
+		thisProp.statistic.kind = statKindTEXT
+	}
+
+	// if we still haven't found out about this property's stat type, let's try figuring it out
+	if thisProp.statistic.kind == statKindTEXT {
+
+		// iterating over all the possible values
+		for value, count := range thisProp.statistic.valueCounts {
+
+			// we can't state anything from an empty value
+			if value != "" {
+
+				// do we have a date here ?
+				if isDate(value) {
+
+					if thisProp.statistic.kind == statKindTEXT {
+						thisProp.statistic.kind = statKindDATE
+
+					} else if thisProp.statistic.kind != statKindDATE {
+						return fmt.Errorf("value '%s' in column %s is a date, whereas the the column type has been detected as of type '%s'",
+							value, thisProp.getPath(), thisProp.statistic.kind)
+					}
+
+					// if not, we might have a category
+				} else if count > 1 { // twice the same text in a column, this is probably a category
+					thisProp.statistic.kind = statKindCATEGORY
+					return nil
+				}
+			}
+		}
+	}
+
+	// for numbers, let's find out if we need decimals of not, from the original literals
+	if thisProp.statistic.kind == statKindNUMBER {
+		for valueString := range thisProp.statistic.valueCounts {
+			thisProp.statistic.decimal = thisProp.statistic.decimal || isDecimal(valueString)
+		}
+	}
+
+	return nil
+}
+
+// writing a particular stat
+func (thisProp *chainedProperty) writeStat(excelFile *workbook, conf *j2tConfig, headerLine, nbRows int, target *statsTarget) error {
+
+	// handling the "header" for this stat - the same as the column's, or its whole path, in a block of its own
+	header := thisProp.getHeader()
+	if thisProp.tableHeader != "" {
+		header = thisProp.tableHeader
+	} else if target.byProperty {
+		header = thisProp.getFlatHeader()
+	}
+	headerRow, col := target.statCell(thisProp, target.line, 0, 1, 0)
+	if errSet := setString(excelFile, target.sheet, headerRow, col, header); errSet != nil {
+		return errSet
+	}
+
+	style, errNewStyle := excelFile.getHeaderStyle(thisProp.conf.background)
+	if errNewStyle != nil {
+		return errNewStyle
+	}
+	if errSet := setStyle(excelFile, target.sheet, headerRow, col, style); errSet != nil {
+		return errSet
+	}
+
+	// a block's header spans the whole block
+	if target.byProperty {
+		if errMerge := mergeCells(excelFile, target.sheet, headerRow, 1, headerRow, len(statsSheetWidths)); errMerge != nil {
+			return errMerge
+		}
+	}
+
+	// writing out the stat type
+	kindRow, col := target.statCell(thisProp, target.line, 1, 1, 0)
+	if errSet := setString(excelFile, target.sheet, kindRow, col, string(thisProp.statistic.kind)); errSet != nil {
+		return errSet
+	}
+
+	style, errNewStyle = excelFile.getStyle(`{"font":{"italic":true}, "alignment":{"horizontal":"center"}}`)
+	if errNewStyle != nil {
+		return errNewStyle
+	}
+	if errSet := setStyle(excelFile, target.sheet, kindRow, col, style); errSet != nil {
+		return errSet
+	}
+
+	// computing the first & last cell coordinates for the current column - on the data sheet, if need be; and total number of rows
+	firstCell, errFirst := getCell(headerLine+1, thisProp.index)
+	if errFirst != nil {
+		return errFirst
+	}
+	lastCell, errLast := getCell(headerLine+nbRows, thisProp.index)
+	if errLast != nil {
+		return errLast
+	}
+	firstCell = target.dataPrefix + firstCell
+
+	// the stats begin here
+	statLine := target.line + 2
+
+	// writing out the stats for this column
+	if thisProp.computationDef == nil || !thisProp.computationDef.NoStat {
+		switch thisProp.statistic.kind {
+		case statKindBOOLEAN:
+			return thisProp.writeBooleanStats(excelFile, conf, target, firstCell, lastCell, statLine, nbRows)
+		case statKindCATEGORY:
+			return thisProp.writeCategoryStats(excelFile, conf, target, firstCell, lastCell, statLine, nbRows)
+		case statKindNUMBER:
+			return thisProp.writeNumberStats(excelFile, conf, target, firstCell, lastCell, statLine, nbRows)
+		}
+	}
+
+	// for the texts & dates, only the null values are counted
+	if thisProp.statistic.nullCount > 0 {
+		return thisProp.writeNullValues(excelFile, conf, target, firstCell, lastCell, 0, statLine, nbRows)
+	}
+
+	return nil
+}
+
+// writing formulae useful to treat a boolean statistic
+func (thisProp *chainedProperty) writeBooleanStats(excelFile *workbook, conf *j2tConfig, target *statsTarget,
+	firstCell, lastCell string, statLine, nbRows int) error {
+	if err := thisProp.writeCategoryValue(excelFile, target, "YES", firstCell, lastCell, 0, statLine, nbRows); err != nil {
+		return err
+	}
+	if err := thisProp.writeCategoryValue(excelFile, target, "NO", firstCell, lastCell, 1, statLine, nbRows); err != nil {
+		return err
+	}
+	return thisProp.writeEmptyValues(excelFile, conf, target, firstCell, lastCell, 2, statLine, nbRows)
+}
+
+// writing a category value
+func (thisProp *chainedProperty) writeCategoryValue(excelFile *workbook, target *statsTarget,
+	value, firstCell, lastCell string, index, statLine, nbRows int) error {
+
+	// writing the value name
+	valueName := "- NC -"
+	if value != "" {
+		valueName = value
+	}
+
+	// counting the occurrences
+	countFormula := "COUNTIF(" + firstCell + ":" + lastCell + ", \"" + value + "\")"
+
+	return thisProp.writeCategoryCount(excelFile, target, valueName, countFormula, index, statLine, nbRows)
+}
+
+// writing the count of a category value, as given by the formula, and its percentage
+func (thisProp *chainedProperty) writeCategoryCount(excelFile *workbook, target *statsTarget,
+	valueName, countFormula string, index, statLine, nbRows int) error {
+
+	// the value name, its count & percentage
+	nameRow, nameCol := target.statCell(thisProp, statLine, index, 3, 0)
+	countRow, countCol := target.statCell(thisProp, statLine, index, 3, 1)
+	percentRow, percentCol := target.statCell(thisProp, statLine, index, 3, 2)
+
+	if errSet := setString(excelFile, target.sheet, nameRow, nameCol, valueName); errSet != nil {
+		return errSet
+	}
+	style, errStyle := excelFile.getStyle(`{"font":{"bold":true}}`)
+	if errStyle != nil {
+		return errStyle
+	}
+	if errSet := setStyle(excelFile, target.sheet, nameRow, nameCol, style); errSet != nil {
+		return errSet
+	}
+
+	// counting the occurrences
+	if errSet := setFormula(excelFile, target.sheet, countRow, countCol, countFormula); errSet != nil {
+		return errSet
+	}
+
+	// computing the percentage
+	countCell, errCount := getCell(countRow, countCol)
+	if errCount != nil {
+		return errCount
+	}
+	if errSet := setFormula(excelFile, target.sheet, percentRow, percentCol, countCell+"/"+strconv.Itoa(nbRows)); errSet != nil {
+		return errSet
+	}
+	style, errStyle = excelFile.getStyle(`{"custom_number_format": "0.0 %"}`)
+	if errStyle != nil {
+		return errStyle
+	}
+	if errSet := setStyle(excelFile, target.sheet, percentRow, percentCol, style); errSet != nil {
+		return errSet
+	}
+
+	// nah, it's ok now
+	return nil
+}
+
+// writing the stats for a category column
+func (thisProp *chainedProperty) writeCategoryStats(excelFile *workbook, conf *j2tConfig, target *statsTarget,
+	firstCell, lastCell string, statLine, nbRows int) error {
+
+	// getting an ordered list for the values; sorting is done by value count, descending
+	values := []string{}
+	for value := range thisProp.statistic.valueCounts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i int, j int) bool {
+		count1 := thisProp.statistic.valueCounts[values[i]]
+		count2 := thisProp.statistic.valueCounts[values[j]]
+		if count1 == count2 {
+			return values[i] < values[j]
+		}
+		return count1 > count2
+	})
+
+	// now let's rool
+	for i, value := range values {
+		if err := thisProp.writeCategoryValue(excelFile, target, value, firstCell, lastCell, i, statLine, nbRows); err != nil {
+			return err
+		}
+	}
+
+	// let's deal with the empty values
+	return thisProp.writeEmptyValues(excelFile, conf, target, firstCell, lastCell, len(values), statLine, nbRows)
+}
+
+// writing the count of the null values, if any, and then the count of the empty values, i.e. the missing ones
+func (thisProp *chainedProperty) writeEmptyValues(excelFile *workbook, conf *j2tConfig, target *statsTarget,
+	firstCell, lastCell string, index, statLine, nbRows int) error {
+
+	emptyFormula := "COUNTIF(" + firstCell + ":" + lastCell + ", \"\")"
+
+	if thisProp.statistic.nullCount > 0 {
+		if err := thisProp.writeNullValues(excelFile, conf, target, firstCell, lastCell, index, statLine, nbRows); err != nil {
+			return err
+		}
+		index++
+
+		// without a marker, the null values are empty cells too
+		if conf.nullValue() == "" {
+			emptyFormula += "-" + strconv.Itoa(thisProp.statistic.nullCount)
+		}
+	}
+
+	return thisProp.writeCategoryCount(excelFile, target, "- NC -", emptyFormula, index, statLine, nbRows)
+}
+
+// writing the count of the null values: the cells with the marker, if there's one, or else the number of null values
+// found while merging, since they're written as empty cells, like the missing values
+func (thisProp *chainedProperty) writeNullValues(excelFile *workbook, conf *j2tConfig, target *statsTarget,
+	firstCell, lastCell string, index, statLine, nbRows int) error {
+	if nullValue := conf.nullValue(); nullValue != "" {
+		return thisProp.writeCategoryValue(excelFile, target, nullValue, firstCell, lastCell, index, statLine, nbRows)
+	}
+	return thisProp.writeCategoryCount(excelFile, target, "- null -", strconv.Itoa(thisProp.statistic.nullCount), index, statLine, nbRows)
+}
+
+// writing the stats for a number column, and then the count of its null values, if any
+func (thisProp *chainedProperty) writeNumberStats(excelFile *workbook, conf *j2tConfig, target *statsTarget, firstCell, lastCell string,
+	statLine, nbRows int) error {
+	numberFormat := "0"
+	if thisProp.statistic.decimal {
+		numberFormat = "0.00"
+	}
+	if err := thisProp.writeNumberStatFn(excelFile, target, firstCell, lastCell, 0, statLine, "MIN", numberFormat); err != nil {
+		return err
+	}
+	if err := thisProp.writeNumberStatFn(excelFile, target, firstCell, lastCell, 1, statLine, "MAX", numberFormat); err != nil {
+		return err
+	}
+	if err := thisProp.writeNumberStatFn(excelFile, target, firstCell, lastCell, 2, statLine, "MEDIAN", numberFormat); err != nil {
+		return err
+	}
+	if !thisProp.statistic.decimal {
+		numberFormat = "0.0"
+	}
+	if err := thisProp.writeNumberStatFn(excelFile, target, firstCell, lastCell, 3, statLine, "AVERAGE", numberFormat); err != nil {
+		return err
+	}
+	if err := thisProp.writeNumberStatFn(excelFile, target, firstCell, lastCell, 4, statLine, "STDEVPA", numberFormat); err != nil {
+		return err
+	}
+	if err := thisProp.writeNumberStatFn(excelFile, target, firstCell, lastCell, 5, statLine, "COUNTA", ""); err != nil {
+		return err
+	}
+	// the null values come right after the 6 stats above
+	if thisProp.statistic.nullCount > 0 {
+		nullLine, _ := target.statCell(thisProp, statLine, 6, 2, 0)
+		return thisProp.writeNullValues(excelFile, conf, target, firstCell, lastCell, 0, nullLine, nbRows)
+	}
+	return nil
+}
+
+// writing a particular stat for a number column
+func (thisProp *chainedProperty) writeNumberStatFn(excelFile *workbook, target *statsTarget,
+	firstCell, lastCell string, index, statLine int, function string, customNumberFormat string) error {
+
+	// the function name, and its result
+	nameRow, nameCol := target.statCell(thisProp, statLine, index, 2, 0)
+	resultRow, resultCol := target.statCell(thisProp, statLine, index, 2, 1)
+
+	if errSet := setString(excelFile, target.sheet, nameRow, nameCol, function); errSet != nil {
+		return errSet
+	}
+
+	// writing down the formula for the function
+	if errSet := setFormula(excelFile, target.sheet, resultRow, resultCol, function+"("+firstCell+":"+lastCell+")"); errSet != nil {
+		return errSet
+	}
+	if customNumberFormat != "" {
+		style, errStyle := excelFile.getStyle(fmt.Sprintf(`{"custom_number_format": "%s"}`, customNumberFormat))
+		if errStyle != nil {
+			return errStyle
+		}
+		if errSet := setStyle(excelFile, target.sheet, resultRow, resultCol, style); errSet != nil {
+			return errSet
+		}
+	}
+
+	return nil
+}
+
+//------------------------------------------------------------------------------
+// Dealing with special types of values
+//------------------------------------------------------------------------------
+
+// is the given value a date ?
+func isDate(value string) bool {
+	if value == "" {
+		return false
+	}
+	_, err := time.Parse("02/01/2006", value)
+	if err != nil {
+		_, err = time.Parse("01/2006", value)
+	}
+	return err == nil
+}
@@ -0,0 +1,135 @@
+//------------------------------------------------------------------------------
+// this code is about writing the sheets of the XLSX file as Excel tables:
+// with 1 header row, with the columns' paths, rather than the merged headers
+// of the sections; and with the data wrapped into a named table, with filters
+// and banded rows
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// the look of the Excel tables
+const tableStyle = "TableStyleMedium2"
+
+var (
+	tableNameInvalidChars = regexp.MustCompile(`[^\pL\pN_.]`)
+	tableNameCellLike     = regexp.MustCompile(`(?i)^([a-z]{1,3}\d+|r\d*c?\d*|c\d*)$`)
+)
+
+// are the sheets written as Excel tables ? yes if asked for as an option, or configured so
+func (config *j2tConfig) getExcelTable(option bool) bool {
+	return option || (config.General != nil && config.General.Table)
+}
+
+// the line of the last header row, under which the data begins
+func (commonDef *fileMap) getHeaderLine(conf *j2tConfig) int {
+	if conf.excelTable {
+		return 1
+	}
+	return commonDef.getHeight()
+}
+
+// writing the headers in 1 row, with the columns' paths - or their configured headers - made unique, since an Excel
+// table cannot have 2 columns with the same name
+func (commonDef *fileMap) writeFlatHeaders(excelFile *workbook) error {
+
+	commonDef.log("\n+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++")
+
+	usedHeaders := map[string]bool{}
+	for _, prop := range commonDef.getColumns() {
+
+		header := prop.getFlatHeader()
+		for i := 2; usedHeaders[strings.ToLower(header)]; i++ {
+			header = fmt.Sprintf("%s (%d)", prop.getFlatHeader(), i)
+		}
+		usedHeaders[strings.ToLower(header)] = true
+		prop.tableHeader = header
+		commonDef.log("dealing with property n°%d = %s", prop.index, header)
+
+		if errSet := setString(excelFile, commonDef.sheet(), 1, prop.index, header); errSet != nil {
+			return errSet
+		}
+
+		// leaving room for the header, and its filter button
+		if errLayout := prop.layOutColumn(excelFile, commonDef.sheet(), prop.index, math.Ceil(float64(len(header))*1.15)+3); errLayout != nil {
+			return errLayout
+		}
+	}
+
+	return nil
+}
+
+// applying the configured colors on the header row
+func (commonDef *fileMap) applyFlatColor(excelFile *workbook) error {
+	for _, prop := range commonDef.getColumns() {
+		style, errNewStyle := excelFile.getHeaderStyle(prop.conf.background)
+		if errNewStyle != nil {
+			return errNewStyle
+		}
+		if errSet := setStyle(excelFile, commonDef.sheet(), 1, prop.index, style); errSet != nil {
+			return errSet
+		}
+	}
+	return nil
+}
+
+// wrapping the headers & the given number of rows into an Excel table, named after the sheet
+func (commonDef *fileMap) addExcelTable(excelFile *workbook, nbRows int) error {
+
+	// an empty sheet does not make a table
+	if len(commonDef.orderedProperties) == 0 {
+		return nil
+	}
+
+	lastCell, errCell := getCell(1+nbRows, commonDef.getLastIndex())
+	if errCell != nil {
+		return errCell
+	}
+
+	format, errFormat := excelFile.getTableFormat(commonDef.sheet())
+	if errFormat != nil {
+		return errFormat
+	}
+
+	return excelFile.AddTable(commonDef.sheet(), "A1", lastCell, format)
+}
+
+// the format of the Excel table of the given sheet, named after it
+func (thisBook *workbook) getTableFormat(sheet string) (string, error) {
+
+	formatBytes, errFormat := json.Marshal(map[string]interface{}{
+		"table_name":       thisBook.getTableName(sheet),
+		"table_style":      tableStyle,
+		"show_row_stripes": true,
+	})
+	if errFormat != nil {
+		return "", errFormat
+	}
+
+	return string(formatBytes), nil
+}
+
+// a unique table name, from the given sheet name: only letters, digits, underscores & periods are allowed, the name
+// cannot begin with a digit, nor look like a cell reference
+func (thisBook *workbook) getTableName(sheet string) string {
+
+	name := tableNameInvalidChars.ReplaceAllString(sheet, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || name[0] == '.' || tableNameCellLike.MatchString(name) {
+		name = "_" + name
+	}
+
+	uniqueName := name
+	for i := 2; thisBook.tables[strings.ToLower(uniqueName)]; i++ {
+		uniqueName = fmt.Sprintf("%s_%d", name, i)
+	}
+	thisBook.tables[strings.ToLower(uniqueName)] = true
+
+	return uniqueName
+}
@@ -0,0 +1,394 @@
+//------------------------------------------------------------------------------
+// the code here validates the scanned JSON documents against a JSON Schema,
+// and lets this schema seed the common definition, with its properties'
+// order & types
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	statusProperty = "_status" // the property telling whether a document is valid against the schema
+	errorsProperty = "_errors" // the property giving the validation errors of a document
+	statusVALID    = "valid"
+	statusINVALID  = "invalid"
+)
+
+// the keywords that would change the validation, but are not supported
+var unsupportedKeywords = []string{"$ref", "$dynamicRef", "allOf", "anyOf", "oneOf", "not", "if", "dependentSchemas", "patternProperties", "prefixItems"}
+
+// a JSON Schema, as used for the validation; only some keywords are supported:
+// type, enum, const, properties, required, additionalProperties, items, minItems, maxItems,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength & pattern
+type validationSchema struct {
+	never                bool                         // for the false schema
+	types                []string                     // if empty, any type is fine
+	enum                 []interface{}                // the possible values, if any
+	hasConst             bool                         // true if there's a const value
+	constValue           interface{}                  // the only possible value, if hasConst
+	properties           map[string]*validationSchema // the schemas of the object's properties
+	propertyOrder        []string                     // the properties, in the schema's order
+	required             []string                     // the properties the object must have
+	additionalProperties *validationSchema            // the schema for the properties not listed, if any
+	items                *validationSchema            // the schema for the array's items, if any
+	minItems, maxItems   int                          // -1 if not set
+	minLength, maxLength int                          // -1 if not set
+	minimum, maximum     *big.Rat                     // nil if not set
+	exclusiveMinimum     *big.Rat                     // nil if not set
+	exclusiveMaximum     *big.Rat                     // nil if not set
+	pattern              *regexp.Regexp               // nil if not set
+}
+
+// reading a JSON Schema, while keeping the order of its properties
+func readValidationSchema(reader io.Reader) (*validationSchema, error) {
+
+	schemaBytes, errRead := io.ReadAll(reader)
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(schemaBytes))
+	decoder.UseNumber()
+	content, order, _, errValue := readValue(decoder, "")
+	if errValue != nil {
+		return nil, errValue
+	}
+
+	return parseValidationSchema(content, order, "#")
+}
+
+// building a schema from its JSON content; the schema path is the one of the schema within the whole schema, for the errors
+func parseValidationSchema(content interface{}, order *keyOrder, schemaPath string) (*validationSchema, error) {
+
+	schema := &validationSchema{minItems: -1, maxItems: -1, minLength: -1, maxLength: -1}
+
+	// the boolean schemas
+	if accepted, isBool := content.(bool); isBool {
+		schema.never = !accepted
+		return schema, nil
+	}
+	keywords, isObject := content.(map[string]interface{})
+	if !isObject {
+		return nil, fmt.Errorf("the schema at '%s' is not an object, nor a boolean", schemaPath)
+	}
+	for _, keyword := range unsupportedKeywords {
+		if _, found := keywords[keyword]; found {
+			return nil, fmt.Errorf("the '%s' keyword, found at '%s', is not supported", keyword, schemaPath)
+		}
+	}
+
+	// the types
+	switch types := keywords["type"].(type) {
+	case string:
+		schema.types = []string{types}
+	case []interface{}:
+		for _, oneType := range types {
+			schema.types = append(schema.types, fmt.Sprintf("%v", oneType))
+		}
+	}
+
+	// the possible values
+	if enum, isArray := keywords["enum"].([]interface{}); isArray {
+		schema.enum = enum
+	}
+	schema.constValue, schema.hasConst = keywords["const"]
+
+	// the object's properties
+	if properties, isObject := keywords["properties"].(map[string]interface{}); isObject {
+		schema.properties = map[string]*validationSchema{}
+		propertiesOrder := order.objects["properties"]
+		for _, propertyName := range propertiesOrder.keys {
+			propertySchema, errProperty := parseValidationSchema(properties[propertyName], propertiesOrder.objects[propertyName],
+				schemaPath+"/properties/"+propertyName)
+			if errProperty != nil {
+				return nil, errProperty
+			}
+			schema.properties[propertyName] = propertySchema
+			schema.propertyOrder = append(schema.propertyOrder, propertyName)
+		}
+	}
+	if required, isArray := keywords["required"].([]interface{}); isArray {
+		for _, propertyName := range required {
+			schema.required = append(schema.required, fmt.Sprintf("%v", propertyName))
+		}
+	}
+	if additional, found := keywords["additionalProperties"]; found {
+		additionalSchema, errAdditional := parseValidationSchema(additional, order.objects["additionalProperties"], schemaPath+"/additionalProperties")
+		if errAdditional != nil {
+			return nil, errAdditional
+		}
+		schema.additionalProperties = additionalSchema
+	}
+
+	// the array's items
+	if items, found := keywords["items"]; found {
+		itemsSchema, errItems := parseValidationSchema(items, order.objects["items"], schemaPath+"/items")
+		if errItems != nil {
+			return nil, errItems
+		}
+		schema.items = itemsSchema
+	}
+
+	// the limits
+	for keyword, limit := range map[string]*int{
+		"minItems": &schema.minItems, "maxItems": &schema.maxItems, "minLength": &schema.minLength, "maxLength": &schema.maxLength,
+	} {
+		if number, isNumber := keywords[keyword].(json.Number); isNumber {
+			value, errInt := number.Int64()
+			if errInt != nil {
+				return nil, fmt.Errorf("invalid '%s' at '%s': %s", keyword, schemaPath, number)
+			}
+			*limit = int(value)
+		}
+	}
+	for keyword, limit := range map[string]**big.Rat{
+		"minimum": &schema.minimum, "maximum": &schema.maximum, "exclusiveMinimum": &schema.exclusiveMinimum, "exclusiveMaximum": &schema.exclusiveMaximum,
+	} {
+		if number, isNumber := keywords[keyword].(json.Number); isNumber {
+			value, isValid := new(big.Rat).SetString(number.String())
+			if !isValid {
+				return nil, fmt.Errorf("invalid '%s' at '%s': %s", keyword, schemaPath, number)
+			}
+			*limit = value
+		}
+	}
+	if pattern, isString := keywords["pattern"].(string); isString {
+		patternRegexp, errPattern := regexp.Compile(pattern)
+		if errPattern != nil {
+			return nil, fmt.Errorf("invalid pattern at '%s': %w", schemaPath, errPattern)
+		}
+		schema.pattern = patternRegexp
+	}
+
+	return schema, nil
+}
+
+// the JSON Schema type of a JSON value
+func schemaType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// validating a JSON value against this schema; the errors found are added to the given ones
+func (schema *validationSchema) validate(value interface{}, valuePath string, errs *[]string) {
+
+	addError := func(format string, args ...interface{}) {
+		shownPath := valuePath
+		if shownPath == "" {
+			shownPath = "(root)"
+		}
+		*errs = append(*errs, fmt.Sprintf("'%s': %s", shownPath, fmt.Sprintf(format, args...)))
+	}
+
+	if schema.never {
+		addError("not allowed")
+		return
+	}
+
+	// the type
+	valueType := schemaType(value)
+	if len(schema.types) > 0 {
+		typeFound := false
+		for _, expectedType := range schema.types {
+			typeFound = typeFound || expectedType == valueType ||
+				expectedType == "integer" && valueType == "number" && isInteger(value)
+		}
+		if !typeFound {
+			addError("expected %s, got %s", strings.Join(schema.types, " or "), valueType)
+			return
+		}
+	}
+
+	// the possible values
+	if schema.enum != nil {
+		found := false
+		for _, possibleValue := range schema.enum {
+			found = found || jsonEquals(value, possibleValue)
+		}
+		if !found {
+			addError("value not among the possible ones: %v", value)
+		}
+	}
+	if schema.hasConst && !jsonEquals(value, schema.constValue) {
+		addError("expected %v, got %v", schema.constValue, value)
+	}
+
+	switch typedValue := value.(type) {
+
+	case map[string]interface{}:
+		for _, propertyName := range schema.required {
+			if _, found := typedValue[propertyName]; !found {
+				addError("missing required property '%s'", propertyName)
+			}
+		}
+		for _, propertyName := range sortedKeys(typedValue) {
+			propertyPath := propertyName
+			if valuePath != "" {
+				propertyPath = valuePath + "/" + propertyName
+			}
+			if propertySchema := schema.properties[propertyName]; propertySchema != nil {
+				propertySchema.validate(typedValue[propertyName], propertyPath, errs)
+			} else if schema.additionalProperties != nil {
+				schema.additionalProperties.validate(typedValue[propertyName], propertyPath, errs)
+			}
+		}
+
+	case []interface{}:
+		if schema.minItems >= 0 && len(typedValue) < schema.minItems {
+			addError("expected at least %d item(s), got %d", schema.minItems, len(typedValue))
+		}
+		if schema.maxItems >= 0 && len(typedValue) > schema.maxItems {
+			addError("expected at most %d item(s), got %d", schema.maxItems, len(typedValue))
+		}
+		if schema.items != nil {
+			for i, item := range typedValue {
+				schema.items.validate(item, fmt.Sprintf("%s/%d", valuePath, i), errs)
+			}
+		}
+
+	case string:
+		length := utf8.RuneCountInString(typedValue)
+		if schema.minLength >= 0 && length < schema.minLength {
+			addError("expected at least %d character(s), got %d", schema.minLength, length)
+		}
+		if schema.maxLength >= 0 && length > schema.maxLength {
+			addError("expected at most %d character(s), got %d", schema.maxLength, length)
+		}
+		if schema.pattern != nil && !schema.pattern.MatchString(typedValue) {
+			addError("'%s' does not match the pattern '%s'", typedValue, schema.pattern)
+		}
+
+	case json.Number:
+		number, _ := new(big.Rat).SetString(typedValue.String())
+		if schema.minimum != nil && number.Cmp(schema.minimum) < 0 {
+			addError("%s is less than the minimum %s", typedValue, schema.minimum.RatString())
+		}
+		if schema.maximum != nil && number.Cmp(schema.maximum) > 0 {
+			addError("%s is more than the maximum %s", typedValue, schema.maximum.RatString())
+		}
+		if schema.exclusiveMinimum != nil && number.Cmp(schema.exclusiveMinimum) <= 0 {
+			addError("%s is not more than %s", typedValue, schema.exclusiveMinimum.RatString())
+		}
+		if schema.exclusiveMaximum != nil && number.Cmp(schema.exclusiveMaximum) >= 0 {
+			addError("%s is not less than %s", typedValue, schema.exclusiveMaximum.RatString())
+		}
+	}
+}
+
+// is this JSON value a number with no fractional part, e.g. 1, 1.0 or 1e3 ?
+func isInteger(value interface{}) bool {
+	number, isNumber := value.(json.Number)
+	if !isNumber {
+		return false
+	}
+	rat, isValid := new(big.Rat).SetString(number.String())
+	return isValid && rat.IsInt()
+}
+
+// comparing 2 JSON values, the numbers being compared by value, e.g. 1 and 1.0 are equal
+func jsonEquals(value interface{}, other interface{}) bool {
+	number, isNumber := value.(json.Number)
+	otherNumber, otherIsNumber := other.(json.Number)
+	if isNumber && otherIsNumber {
+		rat, isValid := new(big.Rat).SetString(number.String())
+		otherRat, otherIsValid := new(big.Rat).SetString(otherNumber.String())
+		return isValid && otherIsValid && rat.Cmp(otherRat) == 0
+	}
+	return reflect.DeepEqual(value, other)
+}
+
+// the keys of a JSON object, sorted, so that the errors always come in the same order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// marking a root map as valid, or not, with its validation errors - as the first properties
+func (rootMap *fileMap) addStatusProperties(errs []string) {
+	rootMap.orderedProperties = append([]string{statusProperty, errorsProperty}, rootMap.orderedProperties...)
+	if len(errs) == 0 {
+		rootMap.values[statusProperty] = statusVALID
+		rootMap.values[errorsProperty] = nil
+	} else {
+		rootMap.values[statusProperty] = statusINVALID
+		rootMap.values[errorsProperty] = strings.Join(errs, "; ")
+	}
+	rootMap.chain()
+}
+
+// the map seeding the common definition, with the schema's properties, in order, each one with a value of the schema's type;
+// the arrays, and the objects with no properties, are left out, while the properties with no type - or only "null" -
+// get a null value, so that they're placed, but get their kind from the documents
+func (schema *validationSchema) getSeed(parent *fileMap, name string) *fileMap {
+
+	seed := &fileMap{
+		name:       name,
+		parent:     parent,
+		subMaps:    map[string]*fileMap{},
+		values:     map[string]interface{}{},
+		arrayItems: map[string][]*fileMap{},
+		seed:       parent == nil,
+	}
+
+	for _, propertyName := range schema.propertyOrder {
+
+		propertySchema := schema.properties[propertyName]
+
+		// the first type that's not null
+		seedType := ""
+		for _, oneType := range propertySchema.types {
+			if oneType != "null" && seedType == "" {
+				seedType = oneType
+			}
+		}
+
+		switch seedType {
+		case "object":
+			if len(propertySchema.propertyOrder) == 0 {
+				continue
+			}
+			seed.subMaps[propertyName] = propertySchema.getSeed(seed, propertyName)
+		case "string":
+			seed.values[propertyName] = ""
+		case "number", "integer":
+			seed.values[propertyName] = json.Number("0")
+		case "boolean":
+			seed.values[propertyName] = false
+		case "":
+			seed.values[propertyName] = nil
+		default:
+			continue
+		}
+
+		seed.orderedProperties = append(seed.orderedProperties, propertyName)
+	}
+
+	return seed.chain()
+}
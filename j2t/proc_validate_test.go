@@ -0,0 +1,128 @@
+//------------------------------------------------------------------------------
+// testing the validation of the JSON documents against a JSON Schema
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// a schema using all the supported keywords
+const testSchema = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 2, "maxLength": 8, "pattern": "^[a-z]+$"},
+		"price": {"type": ["number", "null"], "exclusiveMinimum": 0, "maximum": 100},
+		"status": {"enum": ["new", "done"]},
+		"version": {"const": 2},
+		"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2},
+		"address": {
+			"type": "object",
+			"properties": {"zip": {"type": "string"}},
+			"additionalProperties": false
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+
+	schema, errSchema := readValidationSchema(strings.NewReader(testSchema))
+	if errSchema != nil {
+		t.Fatalf("could not read the schema: %v", errSchema)
+	}
+
+	cases := []struct {
+		name     string
+		document string
+		errs     []string
+	}{
+		{
+			name:     "valid",
+			document: `{"id": 1, "name": "alpha", "price": 9.99, "status": "new", "version": 2.0, "tags": ["x"], "address": {"zip": "75001"}}`,
+		},
+		{
+			name:     "integers written as decimals",
+			document: `{"id": 1.0, "name": "gamma", "version": 2}`,
+		},
+		{
+			name:     "integers written with an exponent",
+			document: `{"id": 1e3, "name": "delta"}`,
+		},
+		{
+			name:     "null allowed",
+			document: `{"id": 12, "name": "beta", "price": null}`,
+		},
+		{
+			name:     "missing required",
+			document: `{"id": 1}`,
+			errs:     []string{"'(root)': missing required property 'name'"},
+		},
+		{
+			name:     "wrong types",
+			document: `{"id": 1.5, "name": 12, "tags": "x"}`,
+			errs: []string{
+				"'id': expected integer, got number",
+				"'name': expected string, got number",
+				"'tags': expected array, got string",
+			},
+		},
+		{
+			name:     "numbers out of range",
+			document: `{"id": 0, "name": "alpha", "price": 0}`,
+			errs: []string{
+				"'id': 0 is less than the minimum 1",
+				"'price': 0 is not more than 0",
+			},
+		},
+		{
+			name:     "strings out of shape",
+			document: `{"id": 1, "name": "Alphabetical"}`,
+			errs: []string{
+				"'name': expected at most 8 character(s), got 12",
+				"'name': 'Alphabetical' does not match the pattern '^[a-z]+$'",
+			},
+		},
+		{
+			name:     "enum & const",
+			document: `{"id": 1, "name": "alpha", "status": "late", "version": 3}`,
+			errs: []string{
+				"'status': value not among the possible ones: late",
+				"'version': expected 2, got 3",
+			},
+		},
+		{
+			name:     "arrays & additional properties",
+			document: `{"id": 1, "name": "alpha", "tags": ["x", 2, "z"], "address": {"zip": "75001", "city": "Paris"}}`,
+			errs: []string{
+				"'address/city': not allowed",
+				"'tags': expected at most 2 item(s), got 3",
+				"'tags/1': expected string, got number",
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			decoder := json.NewDecoder(bytes.NewReader([]byte(testCase.document)))
+			decoder.UseNumber()
+			var document interface{}
+			if errDecode := decoder.Decode(&document); errDecode != nil {
+				t.Fatalf("could not read the document: %v", errDecode)
+			}
+
+			errs := []string{}
+			schema.validate(document, "", &errs)
+			if len(errs) != len(testCase.errs) || len(errs) > 0 && !reflect.DeepEqual(errs, testCase.errs) {
+				t.Errorf("expected the errors:\n%s\ngot:\n%s", strings.Join(testCase.errs, "\n"), strings.Join(errs, "\n"))
+			}
+		})
+	}
+}
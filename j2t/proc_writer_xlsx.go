@@ -0,0 +1,476 @@
+//------------------------------------------------------------------------------
+// this code is about writing the XLSX file from the common definition,
+// with 1 line per original JSON file
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	excel "github.com/360EntSecGroup-Skylar/excelize"
+)
+
+const (
+	mainSheetName = "results"
+)
+
+// writing the excel file
+func (commonDef *fileMap) writeExcel(conf *j2tConfig, jsonMaps []*fileMap, open Opener) error {
+
+	// creating the file and the main sheet
+	excelFile := newWorkbook()
+	excelFile.SetSheetName("Sheet1", mainSheetName)
+
+	// writing the main sheet, then the child sheets, if any
+	sheets := append([]*childSheet{{commonDef: commonDef, jsonMaps: jsonMaps}}, commonDef.childSheets...)
+	statsSheets := []*childSheet{}
+	for i, sheet := range sheets {
+		if i > 0 {
+			excelFile.NewSheet(sheet.commonDef.sheet())
+		}
+		if errSheet := sheet.commonDef.writeSheet(excelFile, conf, sheet.jsonMaps); errSheet != nil {
+			return errSheet
+		}
+		if conf.statsPlace == statsPlaceSHEET {
+			statsSheets = append(statsSheets, sheet)
+		}
+	}
+
+	// writing stats on their own sheet, if configured so
+	if len(statsSheets) > 0 {
+		if errStats := writeStatsSheet(excelFile, conf, statsSheets); errStats != nil {
+			return errStats
+		}
+	}
+
+	// saving the file
+	writer, errOpen := open(".xlsx")
+	if errOpen != nil {
+		return &WriteError{Err: fmt.Errorf("could not create the Excel file: %w", errOpen)}
+	}
+	if errSave := excelFile.Write(writer); errSave != nil {
+		writer.Close()
+		return &WriteError{Err: fmt.Errorf("could not save the Excel file: %w", errSave)}
+	}
+
+	// we're done
+	return writer.Close()
+}
+
+// writing the sheet corresponding to this common definition, with 1 line per JSON map - streamed if there are too many
+func (commonDef *fileMap) writeSheet(excelFile *workbook, conf *j2tConfig, jsonMaps []*fileMap) error {
+
+	// reordering - just to be sure - then computing the index for each final property contained within the definition
+	commonDef.reorder()
+	currentIndex := 1
+	commonDef.index(&currentIndex)
+
+	// writing out the headers - in 1 row for an Excel table
+	headerLine := commonDef.getHeaderLine(conf)
+	var errHeader error
+	if conf.excelTable {
+		errHeader = commonDef.writeFlatHeaders(excelFile)
+	} else {
+		errHeader = commonDef.writeHeaders(excelFile, headerLine)
+	}
+	if errHeader != nil {
+		return &WriteError{Err: fmt.Errorf("could not write the headers of sheet '%s': %w", commonDef.sheet(), errHeader)}
+	}
+
+	// styling the headers
+	if errStyle := commonDef.styleHeaders(excelFile, conf); errStyle != nil {
+		return &WriteError{Err: fmt.Errorf("could not style sheet '%s': %w", commonDef.sheet(), errStyle)}
+	}
+
+	// writing the content - or streaming it, along with the table & the stats below the data
+	if conf.isStreamed(jsonMaps) {
+		return commonDef.streamSheet(excelFile, conf, jsonMaps, headerLine)
+	}
+	if errContent := commonDef.writeLines(excelFile, conf, jsonMaps, headerLine); errContent != nil {
+		return errContent
+	}
+
+	// wrapping the content into an Excel table, if configured so
+	if conf.excelTable {
+		if errTable := commonDef.addExcelTable(excelFile, len(jsonMaps)); errTable != nil {
+			return &WriteError{Err: fmt.Errorf("could not add the table of sheet '%s': %w", commonDef.sheet(), errTable)}
+		}
+	}
+
+	// writing some stats below the data, unless they go on their own sheet
+	if conf.statsPlace != statsPlaceSHEET {
+		target := &statsTarget{sheet: commonDef.sheet(), line: headerLine + len(jsonMaps) + 2}
+		if errStat := commonDef.writeStats(excelFile, conf, headerLine, len(jsonMaps), target); errStat != nil {
+			return errStat
+		}
+	}
+
+	return nil
+}
+
+// writing the Excel file's headers
+func (commonDef *fileMap) writeHeaders(excelFile *workbook, headerLine int) error {
+
+	commonDef.log("\n+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++")
+	if commonDef.root().debugMode {
+		commonDef.log("dealing with section %s", commonDef.getFullName())
+	}
+
+	// following the order
+	for _, property := range commonDef.orderedProperties {
+
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+
+			// writing the name for this section
+			sectionHeader := commonDef.chainedProperties[property].getHeader()
+			if errSet := setString(excelFile, commonDef.sheet(), subMap.getDepth()-1, subMap.getFirstIndex(), sectionHeader); errSet != nil {
+				return errSet
+			}
+
+			// merging the whole section
+			if errMerge := mergeCells(excelFile, commonDef.sheet(),
+				subMap.getDepth()-1, subMap.getFirstIndex(),
+				subMap.getDepth()-1, subMap.getLastIndex()); errMerge != nil {
+				return errMerge
+			}
+
+			// dealing with what's below
+			if errWrite := subMap.writeHeaders(excelFile, headerLine); errWrite != nil {
+				return errWrite
+			}
+
+		} else {
+
+			// retrieving the right property
+			prop := commonDef.chainedProperties[property]
+			commonDef.log("dealing with property n°%d = %s", prop.index, prop.getPath())
+
+			// writing it
+			if errSet := setString(excelFile, commonDef.sheet(), prop.owner.getDepth(), prop.index, prop.getHeader()); errSet != nil {
+				return errSet
+			}
+
+			// merging till the header line
+			if errMerge := mergeCells(excelFile, commonDef.sheet(),
+				prop.owner.getDepth(), prop.index,
+				headerLine, prop.index); errMerge != nil {
+				return errMerge
+			}
+
+			// hiding the column, or adjusting its size
+			if errLayout := prop.layOutColumn(excelFile, commonDef.sheet(), prop.index, 0); errLayout != nil {
+				return errLayout
+			}
+		}
+	}
+
+	return nil
+}
+
+// hiding the given column of the given sheet if this property is configured so, or else adjusting its size - not both,
+// since each call adds a column definition; unless configured, the width is at least the given one
+func (thisProp *chainedProperty) layOutColumn(excelFile *workbook, sheet string, col int, minWidth float64) error {
+	column, errCol := excel.ColumnNumberToName(col)
+	if errCol != nil {
+		return errCol
+	}
+	if thisProp.conf.hidden {
+		return excelFile.SetColVisible(sheet, column, false)
+	}
+	width := thisProp.getColumnWidth()
+	if width < minWidth && thisProp.conf.width == 0 {
+		width = minWidth
+	}
+	return excelFile.SetColWidth(sheet, column, column, width)
+}
+
+// the width of the column for this property, as configured, or else given the longest value
+func (thisProp *chainedProperty) getColumnWidth() float64 {
+	if thisProp.conf != nil && thisProp.conf.width > 0 {
+		return thisProp.conf.width
+	}
+	return thisProp.getComputedWidth()
+}
+
+// the width of the column for this property, given the longest value
+func (thisProp *chainedProperty) getComputedWidth() float64 {
+	if columnWidth := math.Ceil(float64(thisProp.maxLength) * 1.15); columnWidth > 8 {
+		return columnWidth
+	}
+	return 8
+}
+
+// writing the Excel file's lines, 1 line per JSON file
+func (commonDef *fileMap) writeLines(excelFile *workbook, conf *j2tConfig, jsonMaps []*fileMap, headerLine int) error {
+	for i, jsonMap := range jsonMaps {
+		if errWrite := commonDef.writeLine(excelFile, conf, jsonMap, headerLine, headerLine+i+1, isZebra(conf, i)); errWrite != nil {
+			return errWrite
+		}
+		commonDef.log("successfully treated JSON file: %s", jsonMap.name)
+	}
+	return nil
+}
+
+// is the line with the given index colored ? 1 line out of 2 is, unless the sheet is an Excel table, which has its own bands
+func isZebra(conf *j2tConfig, index int) bool {
+	return index%2 == 0 && !conf.excelTable
+}
+
+// writing out 1 JSON file
+func (commonDef *fileMap) writeLine(excelFile *workbook, conf *j2tConfig, jsonMap *fileMap, headerLine, currentLine int, even bool) error {
+
+	// excelFile.SetCellValue("", "", "")
+
+	if jsonMap != nil {
+
+		// always following the order
+		for _, property := range commonDef.orderedProperties {
+
+			if subMap := commonDef.subMaps[property]; subMap != nil {
+				if errWrite := subMap.writeLine(excelFile, conf, jsonMap.subMaps[property], headerLine, currentLine, even); errWrite != nil {
+					return errWrite
+				}
+			} else {
+				commonProp := commonDef.chainedProperties[property]
+				if errWrite := commonDef.writeCell(excelFile, conf, jsonMap, commonProp, headerLine, currentLine, even); errWrite != nil {
+					cell, _ := getCell(currentLine, commonProp.index)
+					return &WriteError{Path: string(commonProp.getPath()), File: jsonMap.root().name, Cell: cell, Err: errWrite}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// writing out 1 value of 1 JSON file, i.e. 1 cell
+func (commonDef *fileMap) writeCell(excelFile *workbook, conf *j2tConfig, jsonMap *fileMap, commonProp *chainedProperty,
+	headerLine, currentLine int, even bool) error {
+
+	sheet := commonDef.sheet()
+
+	// checking we're in the right column ! - once per column is enough
+	if currentLine == headerLine+1 {
+		header, errHeader := getString(excelFile, sheet, headerLine, commonProp.index)
+		if errHeader != nil {
+			return errHeader
+		}
+		expectedHeader := commonProp.getHeader()
+		if conf.excelTable {
+			expectedHeader = commonProp.tableHeader
+		}
+		if header != expectedHeader {
+			return fmt.Errorf("at column %d, header says '%s', but we're dealing with '%s'", commonProp.index, header, expectedHeader)
+		}
+	}
+
+	// are we dealing with a computed property ?
+	if commonProp.computed {
+		formula, errFormula := commonProp.getFormula(currentLine)
+		if errFormula != nil {
+			return errFormula
+		}
+		if errSet := setFormula(excelFile, sheet, currentLine, commonProp.index, formula); errSet != nil {
+			return errSet
+		}
+
+	} else {
+
+		// copying the value - if any - into the excel file
+		value, errValue := commonProp.getCellValue(conf, jsonMap)
+		if errValue != nil {
+			return errValue
+		}
+		var errSet error
+		switch typedValue := value.(type) {
+		case bool:
+			errSet = setBool(excelFile, sheet, currentLine, commonProp.index, typedValue)
+		case string:
+			errSet = setString(excelFile, sheet, currentLine, commonProp.index, typedValue)
+		case float64:
+			errSet = setFloat(excelFile, sheet, currentLine, commonProp.index, typedValue)
+		case json.Number:
+			errSet = setNumber(excelFile, sheet, currentLine, commonProp.index, typedValue)
+		}
+		if errSet != nil {
+			return errSet
+		}
+	}
+
+	// oh, maybe we could do a bit of styling here
+	style, errStyle := commonProp.getCellStyle(excelFile, even)
+	if errStyle != nil {
+		return errStyle
+	}
+	if style != 0 {
+		if errSet := setStyle(excelFile, sheet, currentLine, commonProp.index, style); errSet != nil {
+			return errSet
+		}
+	}
+
+	return nil
+}
+
+// the value of this property to write for the given JSON map: nil if there's nothing to write, or else a bool, a string,
+// a float64 or a json.Number - a null value being written as the configured marker, if any
+func (commonProp *chainedProperty) getCellValue(conf *j2tConfig, jsonMap *fileMap) (interface{}, error) {
+
+	// does the current JSON have this property ?
+	if jsonMap.chainedProperties[commonProp.name] == nil {
+		return nil, nil
+	}
+
+	value := jsonMap.values[commonProp.name]
+	if value == nil {
+		if nullValue := conf.nullValue(); nullValue != "" {
+			return nullValue, nil
+		}
+		return nil, nil
+	}
+
+	switch typedValue := value.(type) {
+	case bool:
+		if commonProp.kind == reflect.Bool {
+			return typedValue, nil
+		}
+	case string:
+		if commonProp.kind == reflect.String {
+			return typedValue, nil
+		}
+	case float64:
+		if commonProp.kind == reflect.Float64 {
+			if typedValue == -999999 {
+				return nil, nil
+			}
+			return typedValue, nil
+		}
+	case json.Number:
+		if commonProp.kind == reflect.Float64 {
+			if typedValue == "-999999" {
+				return nil, nil
+			}
+			return typedValue, nil
+		}
+	}
+
+	return nil, commonProp.conflictWith(jsonMap, value)
+}
+
+// the style of this property's cells: 1 line out of 2 is colored, and the values can have a configured number format;
+// 0 if there's no style to apply
+func (commonProp *chainedProperty) getCellStyle(excelFile *workbook, even bool) (int, error) {
+	styleParts := []string{}
+	if even {
+		styleParts = append(styleParts,
+			fmt.Sprintf(`"fill":{"type":"pattern","color":["%s"],"pattern":1}`, getAdjustedColor(commonProp.conf.background, 90, true)))
+	}
+	if numberFormat := commonProp.conf.numberFormat; numberFormat != "" {
+		numberFormatBytes, errFormat := json.Marshal(numberFormat)
+		if errFormat != nil {
+			return 0, errFormat
+		}
+		styleParts = append(styleParts, fmt.Sprintf(`"custom_number_format":%s`, numberFormatBytes))
+	}
+	if len(styleParts) == 0 {
+		return 0, nil
+	}
+	return excelFile.getStyle("{" + strings.Join(styleParts, ",") + "}")
+}
+
+// apply a basic style on the Excel file
+func (commonDef *fileMap) styleHeaders(excelFile *workbook, conf *j2tConfig) error {
+
+	style := fmt.Sprintf(`{
+  "freeze": true,
+  "split": false,
+  "x_split": 1,
+  "y_split": % d,
+  "top_left_cell": "B10",
+  "active_pane": "topRight",
+  "panes": [{
+    "sqref": "B10",
+    "active_cell": "B10",
+    "pane": "topRight"
+  }]
+}`, commonDef.getHeaderLine(conf))
+
+	// dealing with the frozen panes
+	excelFile.SetPanes(commonDef.sheet(), style)
+
+	// applying some colors
+	var errColor error
+	if conf.excelTable {
+		errColor = commonDef.applyFlatColor(excelFile)
+	} else {
+		errColor = commonDef.applyColor(excelFile)
+	}
+	if errColor != nil {
+		return errColor
+	}
+
+	return nil
+}
+
+// applying colors configured in the given config map for the current common definition map
+func (commonDef *fileMap) applyColor(excelFile *workbook) error {
+
+	// coloring each property
+	for propertyName, prop := range commonDef.chainedProperties {
+
+		// getting the right config item
+		// confItem := confMap.items[propertyName]
+		confItem := prop.conf
+
+		// where should the style apply ?
+		row, col := prop.owner.getDepth(), prop.index
+
+		// is it a section we're dealing with ?
+		if subMap := commonDef.subMaps[propertyName]; subMap != nil {
+
+			row, col = subMap.getDepth()-1, subMap.getFirstIndex()
+
+			// going under
+			if errColor := subMap.applyColor(excelFile); errColor != nil {
+				return errColor
+			}
+		}
+
+		// applying the style to the current property
+		style, errNewStyle := excelFile.getHeaderStyle(confItem.background)
+		if errNewStyle != nil {
+			return errNewStyle
+		}
+		if errSet := setStyle(excelFile, commonDef.sheet(), row, col, style); errSet != nil {
+			return errSet
+		}
+	}
+
+	return nil
+}
+
+// the formula of this computed property at the given row
+func (commonProp *chainedProperty) getFormula(row int) (string, error) {
+	newCol := commonProp.computationDef
+	columns := []interface{}{}
+	for _, column := range newCol.columns {
+		cell, errCell := getCell(row, column.index)
+		if errCell != nil {
+			return "", errCell
+		}
+		columns = append(columns, cell)
+	}
+	formula := fmt.Sprintf(newCol.formattableFormula, columns...)
+	root := commonProp.owner.root()
+	if root.firstFormulaRow == 0 {
+		root.firstFormulaRow = row
+	}
+	if row == root.firstFormulaRow {
+		root.log("Formula at row %d col %d: %s", row, commonProp.index, formula)
+	}
+	return formula, nil
+}
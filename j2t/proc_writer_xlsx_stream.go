@@ -0,0 +1,301 @@
+//------------------------------------------------------------------------------
+// this code is about writing the big sheets of the XLSX file: their rows are
+// not built in memory, but streamed with excelize's stream writer, 1 row at a
+// time, with styles registered beforehand; the computed columns keep their
+// formulae, and the stats below the data are written in memory first, then read
+// back, and streamed after the rows
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	excel "github.com/360EntSecGroup-Skylar/excelize"
+)
+
+// above this number of rows, a sheet's rows are streamed, unless another threshold is given
+const defaultStreamingThreshold = 10000
+
+// the number of rows above which a sheet is streamed; the threshold given as an option wins over the configured one
+func (config *j2tConfig) getStreamingThreshold(option int) (int, error) {
+
+	threshold := option
+	if threshold == 0 && config.General != nil {
+		threshold = config.General.StreamingThreshold
+	}
+	if threshold == 0 {
+		threshold = defaultStreamingThreshold
+	}
+	if threshold < 0 {
+		return 0, fmt.Errorf("invalid streaming threshold: %d; it should be a positive number of rows", threshold)
+	}
+
+	return threshold, nil
+}
+
+// are there too many rows to build the sheet in memory ?
+func (config *j2tConfig) isStreamed(jsonMaps []*fileMap) bool {
+	return len(jsonMaps) > config.streamingThreshold
+}
+
+// streaming the rows of this sheet, 1 row per JSON map, the headers having been written - and styled - as usual, then
+// the stats, if they go below the data
+func (commonDef *fileMap) streamSheet(excelFile *workbook, conf *j2tConfig, jsonMaps []*fileMap, headerLine int) error {
+
+	sheet := commonDef.sheet()
+	sheetPath := fmt.Sprintf("xl/worksheets/sheet%d.xml", excelFile.GetSheetIndex(sheet))
+	lastCol := commonDef.getLastIndex()
+	lastCell, errCell := getCell(headerLine+len(jsonMaps), lastCol)
+	if errCell != nil {
+		return &WriteError{Err: errCell}
+	}
+	lastRow := headerLine + len(jsonMaps)
+
+	// the styles of all the columns, registered up front
+	styles := map[*chainedProperty][2]int{}
+	if errStyle := commonDef.buildStyles(excelFile, styles); errStyle != nil {
+		return &WriteError{Err: fmt.Errorf("could not style sheet '%s': %w", sheet, errStyle)}
+	}
+
+	// the stream writer rewrites all the rows, so the headers are taken from the sheet; and it writes the merged cells
+	// before the rows, where Excel does not expect them, so they're put back after the rows, once flushed
+	merges, errMerges := excelFile.GetMergeCells(sheet)
+	if errMerges != nil {
+		return &WriteError{Err: fmt.Errorf("could not read the merged cells of sheet '%s': %w", sheet, errMerges)}
+	}
+	if workSheet := excelFile.Sheet[sheetPath]; workSheet != nil {
+		workSheet.MergeCells = nil
+	}
+	headers := make([][]interface{}, headerLine)
+	for row := 1; row <= headerLine; row++ {
+		cells, errCells := excelFile.getRowCells(sheet, row, lastCol)
+		if errCells != nil {
+			return &WriteError{Err: fmt.Errorf("could not read the headers of sheet '%s': %w", sheet, errCells)}
+		}
+		headers[row-1] = cells
+	}
+
+	// the stats below the data are written in the sheet as usual, then taken from it, to be streamed after the rows
+	stats := map[int][]interface{}{}
+	if conf.statsPlace != statsPlaceSHEET {
+		target := &statsTarget{sheet: sheet, line: lastRow + 2}
+		if errStat := commonDef.writeStats(excelFile, conf, headerLine, len(jsonMaps), target); errStat != nil {
+			return errStat
+		}
+		for row := target.line; row <= target.lastLine; row++ {
+			cells, errCells := excelFile.getRowCells(sheet, row, lastCol)
+			if errCells != nil {
+				return &WriteError{Err: fmt.Errorf("could not read the stats of sheet '%s': %w", sheet, errCells)}
+			}
+			stats[row] = cells
+		}
+		if target.lastLine > lastRow {
+			lastRow = target.lastLine
+		}
+	}
+	if workSheet := excelFile.Sheet[sheetPath]; workSheet != nil && workSheet.Dimension != nil {
+		lastRef, errRef := getCell(lastRow, lastCol)
+		if errRef != nil {
+			return &WriteError{Err: errRef}
+		}
+		workSheet.Dimension.Ref = "A1:" + lastRef
+	}
+
+	// streaming the headers, then the JSON maps
+	streamWriter, errStream := excelFile.NewStreamWriter(sheet)
+	if errStream != nil {
+		return &WriteError{Err: fmt.Errorf("could not stream sheet '%s': %w", sheet, errStream)}
+	}
+	for i, cells := range headers {
+		if errRow := streamWriter.SetRow(fmt.Sprintf("A%d", i+1), cells); errRow != nil {
+			return &WriteError{Err: fmt.Errorf("could not write the headers of sheet '%s': %w", sheet, errRow)}
+		}
+	}
+	for i, jsonMap := range jsonMaps {
+		row := headerLine + i + 1
+		cells := make([]interface{}, lastCol)
+		if errCells := commonDef.getStreamedCells(conf, jsonMap, jsonMap, row, styles, isZebra(conf, i), cells); errCells != nil {
+			return errCells
+		}
+		if errRow := streamWriter.SetRow(fmt.Sprintf("A%d", row), trimCells(cells)); errRow != nil {
+			return &WriteError{File: jsonMap.name, Err: fmt.Errorf("could not write row %d of sheet '%s': %w", row, sheet, errRow)}
+		}
+		commonDef.log("successfully treated JSON file: %s", jsonMap.name)
+	}
+	for row := headerLine + len(jsonMaps) + 1; row <= lastRow; row++ {
+		if cells := stats[row]; len(cells) > 0 {
+			if errRow := streamWriter.SetRow(fmt.Sprintf("A%d", row), cells); errRow != nil {
+				return &WriteError{Err: fmt.Errorf("could not write the stats of sheet '%s': %w", sheet, errRow)}
+			}
+		}
+	}
+
+	// wrapping it all into an Excel table, if configured so
+	if conf.excelTable {
+		format, errFormat := excelFile.getTableFormat(sheet)
+		if errFormat != nil {
+			return &WriteError{Err: errFormat}
+		}
+		if errTable := streamWriter.AddTable("A1", lastCell, format); errTable != nil {
+			return &WriteError{Err: fmt.Errorf("could not add the table of sheet '%s': %w", sheet, errTable)}
+		}
+	}
+
+	if errFlush := streamWriter.Flush(); errFlush != nil {
+		return &WriteError{Err: fmt.Errorf("could not stream sheet '%s': %w", sheet, errFlush)}
+	}
+	excelFile.addMergeCells(sheetPath, merges)
+
+	return nil
+}
+
+// building the styles of the columns within this definition: for each column, the style of the odd rows, and the one
+// of the even rows
+func (commonDef *fileMap) buildStyles(excelFile *workbook, styles map[*chainedProperty][2]int) error {
+	for _, property := range commonDef.orderedProperties {
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+			if errStyle := subMap.buildStyles(excelFile, styles); errStyle != nil {
+				return errStyle
+			}
+		} else {
+			commonProp := commonDef.chainedProperties[property]
+			oddStyle, errOdd := commonProp.getCellStyle(excelFile, false)
+			if errOdd != nil {
+				return errOdd
+			}
+			evenStyle, errEven := commonProp.getCellStyle(excelFile, true)
+			if errEven != nil {
+				return errEven
+			}
+			styles[commonProp] = [2]int{oddStyle, evenStyle}
+		}
+	}
+	return nil
+}
+
+// the cells of the given row, up to the given column, with their values - or formulae, already escaped - & styles, as
+// the stream writer takes them; the sheet should not have merged cells anymore, since a merged cell's value is given for
+// all the cells it covers
+func (thisBook *workbook) getRowCells(sheet string, row, lastCol int) ([]interface{}, error) {
+	cells := make([]interface{}, lastCol)
+	for col := 1; col <= lastCol; col++ {
+		cell, errCell := getCell(row, col)
+		if errCell != nil {
+			return nil, errCell
+		}
+		value, errValue := thisBook.GetCellValue(sheet, cell)
+		if errValue != nil {
+			return nil, errValue
+		}
+		formula, errFormula := thisBook.GetCellFormula(sheet, cell)
+		if errFormula != nil {
+			return nil, errFormula
+		}
+		style, errStyle := thisBook.GetCellStyle(sheet, cell)
+		if errStyle != nil {
+			return nil, errStyle
+		}
+		if value != "" || formula != "" || style != 0 {
+			cells[col-1] = excel.Cell{StyleID: style, Formula: formula, Value: value}
+		}
+	}
+	return trimCells(cells), nil
+}
+
+// putting the given merged cells into the given flushed sheet, right after its rows
+func (thisBook *workbook) addMergeCells(sheetPath string, merges []excel.MergeCell) {
+
+	if len(merges) == 0 {
+		return
+	}
+
+	mergeXML := &strings.Builder{}
+	fmt.Fprintf(mergeXML, `<mergeCells count="%d">`, len(merges))
+	for _, merge := range merges {
+		fmt.Fprintf(mergeXML, `<mergeCell ref="%s"></mergeCell>`, merge[0])
+	}
+	mergeXML.WriteString(`</mergeCells>`)
+
+	sheetXML := thisBook.XLSX[sheetPath]
+	end := bytes.LastIndex(sheetXML, []byte(`</sheetData>`)) + len(`</sheetData>`)
+	completeXML := make([]byte, 0, len(sheetXML)+mergeXML.Len())
+	completeXML = append(completeXML, sheetXML[:end]...)
+	completeXML = append(completeXML, mergeXML.String()...)
+	thisBook.XLSX[sheetPath] = append(completeXML, sheetXML[end:]...)
+}
+
+// the cells of 1 JSON file, following the order, each one at its column's index; the cells of a section the JSON file
+// does not have are left empty, as when the sheet is built in memory
+func (commonDef *fileMap) getStreamedCells(conf *j2tConfig, rootMap, jsonMap *fileMap, row int, styles map[*chainedProperty][2]int,
+	even bool, cells []interface{}) error {
+
+	if jsonMap == nil {
+		return nil
+	}
+
+	for _, property := range commonDef.orderedProperties {
+
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+			if errCells := subMap.getStreamedCells(conf, rootMap, jsonMap.subMaps[property], row, styles, even, cells); errCells != nil {
+				return errCells
+			}
+			continue
+		}
+
+		commonProp := commonDef.chainedProperties[property]
+		style := styles[commonProp][0]
+		if even {
+			style = styles[commonProp][1]
+		}
+
+		// a computed property keeps its formula
+		if commonProp.computed {
+			formula, errFormula := commonProp.getFormula(row)
+			if errFormula != nil {
+				return &WriteError{Path: string(commonProp.getPath()), File: rootMap.name, Err: errFormula}
+			}
+			cells[commonProp.index-1] = excel.Cell{StyleID: style, Formula: escapeFormula(formula)}
+			continue
+		}
+
+		value, errValue := commonProp.getStreamedValue(conf, jsonMap)
+		if errValue != nil {
+			return &WriteError{Path: string(commonProp.getPath()), File: rootMap.name, Err: errValue}
+		}
+		if value != nil || style != 0 {
+			cells[commonProp.index-1] = excel.Cell{StyleID: style, Value: value}
+		}
+	}
+
+	return nil
+}
+
+// the value of this property for the given JSON map, as the stream writer takes it
+func (commonProp *chainedProperty) getStreamedValue(conf *j2tConfig, jsonMap *fileMap) (interface{}, error) {
+
+	value, errValue := commonProp.getCellValue(conf, jsonMap)
+	switch typedValue := value.(type) {
+	case bool:
+		return yesNo(typedValue), nil
+	case json.Number:
+		if excelCanHold(typedValue) {
+			return typedValue.Float64()
+		}
+		return typedValue.String(), nil
+	}
+
+	return value, errValue
+}
+
+// the given cells, without the empty ones at the end
+func trimCells(cells []interface{}) []interface{} {
+	end := len(cells)
+	for end > 0 && cells[end-1] == nil {
+		end--
+	}
+	return cells[:end]
+}
@@ -3,7 +3,7 @@
 // present in a JSON file and how they are ordered
 //------------------------------------------------------------------------------
 
-package main
+package j2t
 
 import (
 	"fmt"
@@ -24,6 +24,7 @@ type chainedProperty struct {
 	statistic      *stat            // this property seen as a statistical variable
 	computed       bool             // if true, then is property is computed from other columns
 	computationDef *newColumnConfig // if this is a computed property, then how to compute stuff is set here
+	tableHeader    string           // the header of the column when the sheet is written as an Excel table
 }
 
 func (thisProperty *chainedProperty) String() string {
@@ -40,10 +41,34 @@ func (thisProperty *chainedProperty) getPath() path {
 	return thisProperty.path
 }
 
+// the path of this property within the config, where the properties of the child sheets are prefixed with their array's path
+func (thisProperty *chainedProperty) getConfigPath() path {
+	if arrayPath := thisProperty.owner.root().arrayPath; arrayPath != "" {
+		return arrayPath + "/" + thisProperty.getPath()
+	}
+	return thisProperty.getPath()
+}
+
+// the header for this property, i.e. its name, if not configured otherwise
+func (thisProperty *chainedProperty) getHeader() string {
+	if thisProperty.conf != nil && thisProperty.conf.header != "" {
+		return thisProperty.conf.header
+	}
+	return thisProperty.name
+}
+
+// the header of this property when all the headers are in 1 row: its configured header, or else its path
+func (thisProperty *chainedProperty) getFlatHeader() string {
+	if thisProperty.conf != nil && thisProperty.conf.header != "" {
+		return thisProperty.conf.header
+	}
+	return string(thisProperty.getPath())
+}
+
 // chaining this property right after the given targeted property
 func (thisProperty *chainedProperty) linkAfter(target *chainedProperty, verbose bool) {
 	if verbose {
-		log("--> new linking : %s -> %s", target, thisProperty)
+		thisProperty.owner.log("--> new linking : %s -> %s", target, thisProperty)
 	}
 	target.next = thisProperty
 	thisProperty.previous = target
@@ -55,9 +80,9 @@ func (thisProperty *chainedProperty) insertAfter(target *chainedProperty) {
 	thisProperty.linkAfter(target, false)
 	if targetNext != nil {
 		targetNext.linkAfter(thisProperty, false)
-		log("--> insertion   : %s -> %s -> %s", target, thisProperty, targetNext)
+		thisProperty.owner.log("--> insertion   : %s -> %s -> %s", target, thisProperty, targetNext)
 	} else {
-		log("--> insertion   : %s -> %s", target, thisProperty)
+		thisProperty.owner.log("--> insertion   : %s -> %s", target, thisProperty)
 	}
 }
 
@@ -66,7 +91,7 @@ func (thisProperty *chainedProperty) insertBefore(target *chainedProperty) {
 	targetPrevious := target.previous
 	thisProperty.linkAfter(targetPrevious, false)
 	target.linkAfter(thisProperty, false)
-	log("--> insertion : %s -> %s -> %s", targetPrevious, thisProperty, target)
+	thisProperty.owner.log("--> insertion : %s -> %s -> %s", targetPrevious, thisProperty, target)
 }
 
 // finding the root
@@ -90,9 +115,12 @@ func (thisProperty *chainedProperty) touches(other *chainedProperty) bool {
 	return thisProperty.next.equals(other)
 }
 
-// returning the string value
+// returning the string value, a null value being seen as empty
 func (thisProperty *chainedProperty) stringValue() string {
-	return fmt.Sprintf("%v", thisProperty.owner.values[thisProperty.name])
+	if value := thisProperty.owner.values[thisProperty.name]; value != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return ""
 }
 
 // setting the value
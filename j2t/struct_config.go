@@ -0,0 +1,170 @@
+//------------------------------------------------------------------------------
+// the code here is about the JSON config file (named after the folder, with a .json extension)
+// that contains useful, customizable info about the Excel file generation
+//------------------------------------------------------------------------------
+
+package j2t
+
+import "encoding/json"
+
+// the struct for the config file
+type j2tConfig struct {
+	General            *generalConfig             `json:"General,omitempty"`
+	NewColumns         []*newColumnConfig         `json:"NewColumns,omitempty"`
+	ModifiedColumns    []*modifiedColumnConfig    `json:"ModifiedColumns,omitempty"`
+	Arrays             []*arrayConfig             `json:"Arrays,omitempty"`
+	CSV                *csvConfig                 `json:"CSV,omitempty"`
+	TypeConflicts      []*typeConflictConfig      `json:"TypeConflicts,omitempty"`
+	ColumnOrder        []path                     `json:"ColumnOrder,omitempty"` // the paths of the columns, in order; "section/*" stands for the section's unlisted columns
+	Columns            []*columnConfig            `json:"Columns,omitempty"`     // generated, then maintained, from the common definition
+	fileName           string                     // the name of the config file, for the error messages
+	columnIndex        map[path]*columnConfig     // the configured columns, indexed by path
+	matchedColumnOrder map[path]bool              // the entries of the column order that have matched a property
+	statsPlace         statsPlace                 // where the stats are written in the Excel file, given the options & the config
+	streamingThreshold int                        // the number of rows above which a sheet of the Excel file is streamed, given the options & the config
+	excelTable         bool                       // if true, the sheets of the Excel file are written as tables, given the options & the config
+	unknown            map[string]json.RawMessage // the members this version does not know, kept as they are when the config is written out
+}
+
+// UnmarshalJSON : keeping the unknown members
+func (config *j2tConfig) UnmarshalJSON(jsonBytes []byte) error {
+	type plainConfig j2tConfig
+	unknown, errUnmarshal := unmarshalKeepingUnknown(jsonBytes, (*plainConfig)(config))
+	config.unknown = unknown
+	return errUnmarshal
+}
+
+// MarshalJSON : writing the unknown members back
+func (config *j2tConfig) MarshalJSON() ([]byte, error) {
+	type plainConfig j2tConfig
+	return marshalWithUnknown((*plainConfig)(config), config.unknown)
+}
+
+// the marker to write for the null values
+func (config *j2tConfig) nullValue() string {
+	if config.General == nil {
+		return ""
+	}
+	return config.General.NullValue
+}
+
+// the config of a property, as used to write it out
+type configItem struct {
+	background   string  // the background color of the header
+	header       string  // the header, if not the property name
+	hidden       bool    // if true, the column is hidden, or not written at all, depending on the output
+	width        float64 // the width of the column, if not computed from its values
+	numberFormat string  // the Excel number format for the values, if any
+}
+
+type generalConfig struct {
+	TrueValue          string                     `json:"TrueValue,omitempty"`
+	FalseValue         string                     `json:"FalseValue,omitempty"`
+	NullValue          string                     `json:"NullValue,omitempty"`          // what's written for a null value; empty by default
+	Outputs            []string                   `json:"Outputs,omitempty"`            // the output formats, e.g. ["xlsx", "csv"], when not given on the command line
+	Include            []string                   `json:"Include,omitempty"`            // the patterns of the files to scan, e.g. ["**/*.json"], when not given on the command line
+	Exclude            []string                   `json:"Exclude,omitempty"`            // the patterns of the files, or folders, not to scan, when not given on the command line
+	RowsPath           path                       `json:"RowsPath,omitempty"`           // the path of the array of objects giving the rows within each JSON document, if any
+	Order              orderMode                  `json:"Order,omitempty"`              // "strict" or "vote", when not given on the command line
+	Stats              statsPlace                 `json:"Stats,omitempty"`              // "below" the data, or on a dedicated "sheet", when not given on the command line
+	Table              bool                       `json:"Table,omitempty"`              // if true, the sheets of the Excel file are written as tables, with 1 header row
+	StreamingThreshold int                        `json:"StreamingThreshold,omitempty"` // the number of rows above which a sheet of the Excel file is streamed, when not given on the command line
+	unknown            map[string]json.RawMessage // the members this version does not know
+}
+
+// UnmarshalJSON : keeping the unknown members
+func (general *generalConfig) UnmarshalJSON(jsonBytes []byte) error {
+	type plainGeneral generalConfig
+	unknown, errUnmarshal := unmarshalKeepingUnknown(jsonBytes, (*plainGeneral)(general))
+	general.unknown = unknown
+	return errUnmarshal
+}
+
+// MarshalJSON : writing the unknown members back
+func (general *generalConfig) MarshalJSON() ([]byte, error) {
+	type plainGeneral generalConfig
+	return marshalWithUnknown((*plainGeneral)(general), general.unknown)
+}
+
+type newColumnConfig struct {
+	Name               string             `json:"Name"`
+	PutAfter           path               `json:"PutAfter"`
+	Formula            string             `json:"Formula"`
+	NoStat             bool               `json:"NoStat,omitempty"`
+	formattableFormula string             // the formula ready to be filled with real column coordinates
+	columns            []*chainedProperty // the columns involved in the definition of the formula
+	expression         formulaNode        // the parsed formula, for the outputs where it has to be evaluated
+}
+
+type modifiedColumnConfig struct {
+	SetColumn path   `json:"SetColumn"`
+	ToValue   string `json:"ToValue"`
+	When      path   `json:"When"`
+	Equals    string `json:"Equals"`
+}
+
+// how an array gets rendered into the table
+type arrayStrategy string
+
+const (
+	arrayStrategyJOIN    arrayStrategy = "join"    // 1 column, with all the items joined into a string
+	arrayStrategyCOLUMNS arrayStrategy = "columns" // 1 column per index, up to the max length seen
+	arrayStrategyCOUNT   arrayStrategy = "count"   // 1 column, with the number of items
+	arrayStrategySHEET   arrayStrategy = "sheet"   // 1 column with the number of items, and 1 row per object in a child sheet
+)
+
+type arrayConfig struct {
+	Path      path          `json:"Path"`
+	Strategy  arrayStrategy `json:"Strategy"`
+	Separator string        `json:"Separator,omitempty"`
+}
+
+// how the type conflicts of 1 property get resolved
+type typeConflictConfig struct {
+	Path   path           `json:"Path"`
+	Policy conflictPolicy `json:"Policy"` // "string", "number", "first" or "split"
+}
+
+// the config of 1 column - or section - of the table; the columns of the child sheets are prefixed with their array's path
+type columnConfig struct {
+	Path          path                       `json:"Path"`
+	Header        string                     `json:"Header,omitempty"`        // the header to display, instead of the property name
+	Hide          bool                       `json:"Hide,omitempty"`          // if true, the column - or the whole section - is hidden in Excel, and not written in CSV
+	Color         string                     `json:"Color,omitempty"`         // the background color of the header, e.g. "#1A5276"
+	Width         float64                    `json:"Width,omitempty"`         // the width of the column; computed from the values if 0
+	ComputedWidth float64                    `json:"ComputedWidth,omitempty"` // the width computed from the values when the column was added, for information
+	NumberFormat  string                     `json:"NumberFormat,omitempty"`  // the Excel number format for the values, e.g. "0.00" or "#,##0"
+	Stat          statKind                   `json:"Stat,omitempty"`          // the kind of stat detected for the column
+	unknown       map[string]json.RawMessage // the members this version does not know, e.g. the user's notes
+}
+
+// UnmarshalJSON : keeping the unknown members
+func (column *columnConfig) UnmarshalJSON(jsonBytes []byte) error {
+	type plainColumn columnConfig
+	unknown, errUnmarshal := unmarshalKeepingUnknown(jsonBytes, (*plainColumn)(column))
+	column.unknown = unknown
+	return errUnmarshal
+}
+
+// MarshalJSON : writing the unknown members back
+func (column *columnConfig) MarshalJSON() ([]byte, error) {
+	type plainColumn columnConfig
+	return marshalWithUnknown((*plainColumn)(column), column.unknown)
+}
+
+// the configured column with the given path, if any
+func (config *j2tConfig) getColumn(columnPath path) *columnConfig {
+	if config.columnIndex == nil {
+		config.columnIndex = map[path]*columnConfig{}
+		for _, column := range config.Columns {
+			config.columnIndex[column.Path] = column
+		}
+	}
+	return config.columnIndex[columnPath]
+}
+
+type csvConfig struct {
+	Delimiter string `json:"Delimiter,omitempty"` // "," by default
+	Quoting   string `json:"Quoting,omitempty"`   // "minimal" by default, or "all"
+	BOM       bool   `json:"BOM,omitempty"`       // if true, the file starts with a UTF-8 BOM
+}
@@ -2,9 +2,10 @@
 // the structure with which
 //------------------------------------------------------------------------------
 
-package main
+package j2t
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -18,9 +19,11 @@ type path string
 // cf. https://stackoverflow.com/a/48301733
 type fileMap struct {
 	name                 string                      // for the root maps, keeping the file name here; for submaps, keeping the property name
+	source               string                      // for a root map read from a stream of documents, or from an array, the name of the stream, or document
 	parent               *fileMap                    // for a submap, keeping its parent
 	subMaps              map[string]*fileMap         // the maps belonging to a map
 	values               map[string]interface{}      // the pure values (non-map) within a map
+	arrayItems           map[string][]*fileMap       // the objects contained in the arrays of this map, built as maps
 	orderedProperties    []string                    // keeping track of the original order of the properties
 	originalContent      map[string]interface{}      // the raw info within this map
 	chainedProperties    map[string]*chainedProperty // the properties kept as chained values
@@ -29,27 +32,88 @@ type fileMap struct {
 	depth                int                         // this data tree's depth
 	path                 path                        // this map's full path within the common definition
 	allChainedProperties map[path]*chainedProperty   // indexing all the chained properties from the root common definition
+	sheetName            string                      // for a root common definition, the sheet it's written into, if not the main one
+	childSheets          []*childSheet               // for a root common definition, the definitions of the arrays exploded into child sheets
+	arrayPath            path                        // for the common definition of a child sheet, the path of its array within the parent definition
+	debugMode            bool                        // for a root common definition, if true, debug messages are printed out
+	firstFormulaRow      int                         // for a root common definition, the row of the formulae that get logged
+	seed                 bool                        // for a root map, true if it's built from a schema, to seed the common definition
+	conflicts            []*typeConflict             // for a root common definition, the type conflicts resolved before merging
+	violations           []*OrderError               // for a root common definition, the ordering conflicts found while merging
+}
+
+// the rows exploded from an array of objects, with their own common definition, to be written into a separate sheet
+type childSheet struct {
+	path      path       // the path of the array, within the parent definition
+	commonDef *fileMap   // the common definition for all the array items
+	jsonMaps  []*fileMap // the array items, each one as a root map
 }
 
 // UnmarshalJSON : keeping the properties' order
-func (thisMap *fileMap) UnmarshalJSON(bytes []byte) error {
+func (thisMap *fileMap) UnmarshalJSON(jsonBytes []byte) error {
+
+	// reading the content, while keeping track of the keys' order
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber() // to keep the numbers exact, e.g. the big IDs
+	content, order, _, errRead := readValue(decoder, "")
+	if errDuplicate, isDuplicate := errRead.(*DuplicateKeyError); isDuplicate {
+		errDuplicate.File = thisMap.name
+	}
+	if errRead != nil {
+		return errRead
+	}
 
-	// classic unmarshalling to get the content
-	if err := json.Unmarshal(bytes, &thisMap.originalContent); err != nil {
-		return err
+	// a null document is an empty one
+	if content == nil {
+		content, order = map[string]interface{}{}, &keyOrder{}
+	}
+	object, isObject := content.(map[string]interface{})
+	if !isObject {
+		return fmt.Errorf("not a JSON object")
 	}
+	thisMap.originalContent = object
 
 	// recursively building the ordered map
-	thisMap.build(bytes)
+	thisMap.build(order)
 
 	return nil
 }
 
-// MarshalJSON : override
+// MarshalJSON : override, keeping the properties' order
 func (thisMap *fileMap) MarshalJSON() ([]byte, error) {
 
-	// we don't really care about marshalling here (for now)
-	return json.Marshal(thisMap)
+	buffer := bytes.NewBufferString("{")
+
+	for i, propertyName := range thisMap.orderedProperties {
+
+		// the separator
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+
+		// the property name
+		nameBytes, errName := json.Marshal(propertyName)
+		if errName != nil {
+			return nil, errName
+		}
+		buffer.Write(nameBytes)
+		buffer.WriteString(":")
+
+		// the property value, which can be a submap
+		var value interface{} = thisMap.values[propertyName]
+		if subMap := thisMap.subMaps[propertyName]; subMap != nil {
+			value = subMap
+		}
+		valueBytes, errValue := json.Marshal(value)
+		if errValue != nil {
+			return nil, errValue
+		}
+		buffer.Write(valueBytes)
+	}
+
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
 }
 
 // showing an ordered map content, with respect to the order of the properties
@@ -84,12 +148,12 @@ func (thisMap *fileMap) oneChainedProperty() *chainedProperty {
 	return nil
 }
 
-// getting the kind for a property of the given built file map
+// getting the kind for a property of the given built file map; a null value is of the reflect.Invalid kind
 func (thisMap *fileMap) getPropertyKind(propertyName string) reflect.Kind {
 	if thisMap.subMaps[propertyName] != nil {
 		return reflect.Map
 	}
-	return reflect.ValueOf(thisMap.values[propertyName]).Kind()
+	return valueKind(thisMap.values[propertyName])
 }
 
 // showing the value for a property of the given built file map
@@ -180,6 +244,30 @@ func (thisMap *fileMap) root() *fileMap {
 	return thisMap.parent.root()
 }
 
+// printing out a debug message, if the common definition this map belongs to is in debug mode
+func (thisMap *fileMap) log(strfmt string, params ...interface{}) {
+	if thisMap.root().debugMode {
+		println(fmt.Sprintf(strfmt, params...))
+	}
+}
+
+// what the root maps are sorted with: their name, or the name of the stream they come from, if any, since they're
+// already ordered within a stream
+func (thisMap *fileMap) sortKey() string {
+	if thisMap.source != "" {
+		return thisMap.source
+	}
+	return thisMap.name
+}
+
+// getting the name of the sheet this common definition is written into
+func (thisMap *fileMap) sheet() string {
+	if sheetName := thisMap.root().sheetName; sheetName != "" {
+		return sheetName
+	}
+	return mainSheetName
+}
+
 // getting the path for this (sub-)map
 func (thisMap *fileMap) getPath() path {
 	if thisMap.path == "" {
@@ -202,6 +290,52 @@ func (thisMap *fileMap) getProp(propPath path) *chainedProperty {
 	return thisMap.root().allChainedProperties[propPath]
 }
 
+// removing a property from this map, while keeping the chaining of the other properties
+func (thisMap *fileMap) removeProperty(propertyName string) {
+
+	// removing the property from the ordered properties
+	orderedProperties := []string{}
+	for _, orderedProperty := range thisMap.orderedProperties {
+		if orderedProperty != propertyName {
+			orderedProperties = append(orderedProperties, orderedProperty)
+		}
+	}
+	thisMap.orderedProperties = orderedProperties
+
+	// removing the value, or submap
+	delete(thisMap.values, propertyName)
+	delete(thisMap.subMaps, propertyName)
+	delete(thisMap.arrayItems, propertyName)
+
+	// rechaining
+	thisMap.chain()
+}
+
+// renaming a property of this map, while keeping its place among the other properties
+func (thisMap *fileMap) renameProperty(propertyName string, newName string) {
+
+	// renaming the property in the ordered properties
+	for i, orderedProperty := range thisMap.orderedProperties {
+		if orderedProperty == propertyName {
+			thisMap.orderedProperties[i] = newName
+		}
+	}
+
+	// moving the value, or submap
+	if subMap := thisMap.subMaps[propertyName]; subMap != nil {
+		subMap.name = newName
+		thisMap.subMaps[newName] = subMap
+		delete(thisMap.subMaps, propertyName)
+	}
+	if value, hasValue := thisMap.values[propertyName]; hasValue {
+		thisMap.values[newName] = value
+		delete(thisMap.values, propertyName)
+	}
+
+	// rechaining
+	thisMap.chain()
+}
+
 // returns a property in a given JSON MAP (not the common definition) thanks to its path
 func (thisMap *fileMap) findProp(propPath path) *chainedProperty {
 
@@ -2,7 +2,7 @@
 // keeping some stats for each property
 //------------------------------------------------------------------------------
 
-package main
+package j2t
 
 type statKind string
 
@@ -17,6 +17,7 @@ const (
 type stat struct {
 	owner       *chainedProperty
 	valueCounts map[string]int
+	nullCount   int // how many times the property has been seen with a null value, which is not counted in valueCounts
 	kind        statKind
 	decimal     bool // if of number kind, do the values have decimal ?
 }
@@ -0,0 +1,57 @@
+//------------------------------------------------------------------------------
+// the Excel file being written, along with the styles registered in it so
+// far, so that each style is registered only once, however many cells use it
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	excel "github.com/360EntSecGroup-Skylar/excelize"
+)
+
+type workbook struct {
+	*excel.File
+	styles map[string]int  // the IDs of the registered styles, by definition
+	tables map[string]bool // the names of the tables added so far, in lower case
+}
+
+// a new, empty, Excel file
+func newWorkbook() *workbook {
+	return &workbook{
+		File:   excel.NewFile(),
+		styles: map[string]int{},
+		tables: map[string]bool{},
+	}
+}
+
+// the ID of the style with the given JSON definition, which is registered if not done yet
+func (thisBook *workbook) getStyle(definition string) (int, error) {
+
+	// the same definition can be written with different spacing
+	key := definition
+	compacted := &bytes.Buffer{}
+	if errCompact := json.Compact(compacted, []byte(definition)); errCompact == nil {
+		key = compacted.String()
+	}
+
+	if style, registered := thisBook.styles[key]; registered {
+		return style, nil
+	}
+	style, errNewStyle := thisBook.NewStyle(definition)
+	if errNewStyle != nil {
+		return 0, errNewStyle
+	}
+	thisBook.styles[key] = style
+
+	return style, nil
+}
+
+// the style of the header cells, with the given background
+func (thisBook *workbook) getHeaderStyle(background string) (int, error) {
+	return thisBook.getStyle(fmt.Sprintf(
+		`{"fill":{"type":"pattern","color":["%s"],"pattern":1}, "font":{"color":"%s"}, "alignment":{"horizontal":"center"}}`, background, white))
+}
@@ -0,0 +1,460 @@
+//------------------------------------------------------------------------------
+// the public API, to use jsons2table as a library: the pipeline goes
+// config -> scan -> merge -> modify -> insert -> write
+//------------------------------------------------------------------------------
+
+// Package j2t serialises multiple JSON documents, with mostly the same structure,
+// into tables: 1 line per JSON document, 1 column per property.
+package j2t
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// the error returned when calling a step that needs the common definition too early
+var errNotMerged = errors.New("the JSON documents have not been merged yet")
+
+// Options : the options for running the pipeline
+type Options struct {
+	Debug              bool     // if true, debug messages are printed out, for this table
+	Continue           bool     // if true, the type conflicts found while merging do not stop the pipeline
+	Formats            []string // the output formats; if empty, the configured ones are used, or else the default ones
+	Include            []string // the patterns of the files to scan, e.g. "**/*.json"; if empty, the configured ones are used, or else DefaultIncludePatterns()
+	Exclude            []string // the patterns of the files, or folders, not to scan; if empty, the configured ones are used
+	RowsPath           string   // the path of the array of objects giving the rows within each JSON document, e.g. "data/results"
+	Jobs               int      // how many files can be parsed at the same time; if 0, as many as CPUs
+	Order              string   // "strict" to stop at the first ordering conflict, or "vote" to pick the order of the majority; if empty, the configured one is used, or else "strict"
+	ExcludeInvalid     bool     // if true, the documents that are not valid against the loaded schema, if any, are left out
+	Stats              string   // "below" to write the Excel stats below the data, or "sheet" to write them on a dedicated sheet; if empty, the configured place is used, or else "below"
+	Table              bool     // if true, the sheets of the Excel file are written as tables, with 1 header row, filters & banded rows
+	StreamingThreshold int      // above this number of rows, a sheet of the Excel file is streamed rather than built in memory; if 0, the configured one is used, or else 10000
+}
+
+// Table : the pipeline turning JSON documents into tables
+type Table struct {
+	name      string                 // the name of the table, e.g. the name of the folder containing the JSON files
+	options   Options                // how to run the pipeline
+	config    *j2tConfig             // the config, read before scanning
+	hasConfig bool                   // true if a config has been loaded, so false if the config file has to be created
+	jsonMaps  []*fileMap             // the scanned JSON documents
+	commonDef *fileMap               // the common definition, built when merging
+	childMaps map[path][]*fileMap    // the items of the arrays exploded into child sheets
+	arrays    map[path]arrayStrategy // the strategy used for each array
+	schema    *validationSchema      // the schema to validate the documents against, if any
+	report    []string               // the summaries of what's been found along the pipeline, e.g. the resolved type conflicts
+}
+
+// NewTable : a new pipeline; the name is used as the name of the config file, i.e. name + ".json"
+func NewTable(name string, options Options) *Table {
+	return &Table{
+		name:    name,
+		options: options,
+		config:  &j2tConfig{fileName: name + ".json"},
+	}
+}
+
+// ConfigFileName : the name of the JSON config file for this table, which is not scanned
+func (table *Table) ConfigFileName() string {
+	return table.name + ".json"
+}
+
+// LoadConfig : reading the JSON config; this must happen before scanning
+func (table *Table) LoadConfig(reader io.Reader) error {
+
+	config, errConf := readConfig(reader)
+	if errConf != nil {
+		return fmt.Errorf("error while reading the config: %w", errConf)
+	}
+	table.config = config
+	table.config.fileName = table.ConfigFileName()
+	table.hasConfig = true
+
+	// checking the outputs & scan settings right away, rather than after all the work
+	if _, errOutputs := table.config.getOutputs(table.options.Formats); errOutputs != nil {
+		return errOutputs
+	}
+	if _, errSettings := table.config.getScanSettings(table.options); errSettings != nil {
+		return errSettings
+	}
+	if _, errSettings := table.config.getMergeSettings(table.options); errSettings != nil {
+		return errSettings
+	}
+	if _, errPlace := table.config.getStatsPlace(table.options.Stats); errPlace != nil {
+		return errPlace
+	}
+	if _, errThreshold := table.config.getStreamingThreshold(table.options.StreamingThreshold); errThreshold != nil {
+		return errThreshold
+	}
+
+	return nil
+}
+
+// ScanFS : scanning all the JSON files of the given file system matching the include patterns - and not the exclude
+// ones - except the config file; with "**", the files can be in sub-folders, which is given by an extra column
+func (table *Table) ScanFS(fsys fs.FS) error {
+
+	settings, errSettings := table.config.getScanSettings(table.options)
+	if errSettings != nil {
+		return errSettings
+	}
+
+	jsonMaps, errScan := scanDir(fsys, table.ConfigFileName(), settings)
+	if errScan != nil {
+		return fmt.Errorf("error while scanning: %w", errScan)
+	}
+	table.jsonMaps = append(table.jsonMaps, jsonMaps...)
+
+	return nil
+}
+
+// Scan : scanning 1 JSON document, which is given a name; this can also be a stream of JSON documents, e.g. JSON Lines,
+// in which case each document is named after the given name, and the line it starts at, e.g. "events.jsonl#line12";
+// a document that is an array of objects - or that has one at the configured rows path - gives 1 row per object
+func (table *Table) Scan(name string, reader io.Reader) error {
+
+	settings, errSettings := table.config.getScanSettings(table.options)
+	if errSettings != nil {
+		return errSettings
+	}
+
+	jsonMaps, errScan := scanReader(name, name, reader, settings.rowsPath)
+	if errScan != nil {
+		return fmt.Errorf("error while scanning: %w", errScan)
+	}
+	table.jsonMaps = append(table.jsonMaps, jsonMaps...)
+
+	return nil
+}
+
+// LoadSchema : reading a JSON Schema to validate the JSON documents against, which also gives the order & types of
+// the properties it describes, to the common definition; this must happen before merging
+func (table *Table) LoadSchema(reader io.Reader) error {
+
+	schema, errSchema := readValidationSchema(reader)
+	if errSchema != nil {
+		return fmt.Errorf("error while reading the schema: %w", errSchema)
+	}
+	table.schema = schema
+
+	return nil
+}
+
+// Validate : validating each scanned JSON document against the loaded schema; each document is then marked as valid,
+// or not, with its errors, in 2 extra columns - or, if asked to, the invalid documents are left out. This must
+// happen after scanning, and before merging
+func (table *Table) Validate() error {
+
+	if table.schema == nil {
+		return fmt.Errorf("there is no schema to validate the JSON documents against")
+	}
+
+	validMaps := []*fileMap{}
+	invalidNames := []string{}
+	for _, jsonMap := range table.jsonMaps {
+		errs := []string{}
+		table.schema.validate(jsonMap.originalContent, "", &errs)
+		if len(errs) > 0 {
+			invalidNames = append(invalidNames, jsonMap.name)
+		}
+		if table.options.ExcludeInvalid {
+			if len(errs) == 0 {
+				validMaps = append(validMaps, jsonMap)
+			}
+		} else {
+			jsonMap.addStatusProperties(errs)
+			validMaps = append(validMaps, jsonMap)
+		}
+	}
+	table.jsonMaps = validMaps
+
+	if len(invalidNames) > 0 {
+		summary := fmt.Sprintf("%d document(s) not valid against the schema: %s", len(invalidNames), listFiles(invalidNames))
+		if table.options.ExcludeInvalid {
+			summary = fmt.Sprintf("%d document(s) not valid against the schema, and left out: %s", len(invalidNames), listFiles(invalidNames))
+		}
+		table.report = append(table.report, summary)
+	}
+
+	return nil
+}
+
+// Merge : building the common definition for all the scanned JSON documents
+func (table *Table) Merge() error {
+
+	if len(table.jsonMaps) == 0 {
+		return fmt.Errorf("there is no JSON document to merge")
+	}
+
+	// a bit of sorting, to make sure the treatment is always the same; the documents from a same stream keep their order
+	sort.SliceStable(table.jsonMaps, func(i int, j int) bool {
+		return table.jsonMaps[i].sortKey() < table.jsonMaps[j].sortKey()
+	})
+
+	// turning the arrays into values or submaps, as configured
+	childMaps, arrays, errArrays := handleArrays(table.config, table.jsonMaps)
+	if errArrays != nil {
+		return fmt.Errorf("error while handling the arrays: %w", errArrays)
+	}
+	table.childMaps = childMaps
+	table.arrays = arrays
+
+	// merging all the maps to determine the common definition
+	settings, errSettings := table.config.getMergeSettings(table.options)
+	if errSettings != nil {
+		return errSettings
+	}
+	if table.schema != nil {
+		if seed := table.schema.getSeed(nil, "schema"); len(seed.orderedProperties) > 0 {
+			settings.seed = seed
+		}
+	}
+	commonDef, errMerge := merge(table.jsonMaps, table.config, "", settings)
+	if errMerge != nil {
+		return fmt.Errorf("error while merging: %w", errMerge)
+	}
+	table.commonDef = commonDef
+	table.report = append(table.report, getConflictSummary(commonDef.conflicts)...)
+	table.report = append(table.report, getOrderSummary(commonDef.violations)...)
+
+	// initialising the config for each property of the common definition
+	if errConf := table.commonDef.initConfigMap(table.config); errConf != nil {
+		return errConf
+	}
+
+	// merging the arrays exploded into child sheets, if any
+	if errChildren := table.commonDef.addChildSheets(table.config, table.childMaps, settings); errChildren != nil {
+		return errChildren
+	}
+	for _, child := range table.commonDef.childSheets {
+		table.report = append(table.report, getConflictSummary(child.commonDef.conflicts)...)
+		table.report = append(table.report, getOrderSummary(child.commonDef.violations)...)
+	}
+
+	// the configured column order may refer to properties that do not exist
+	table.report = append(table.report, table.config.getUnknownColumnOrder()...)
+
+	return nil
+}
+
+// Report : the summaries of what's been found - and dealt with - along the pipeline so far, without stopping it, e.g. the
+// type conflicts resolved while merging; 1 line each, for the caller to show
+func (table *Table) Report() []string {
+	return table.report
+}
+
+// Modify : changing some columns, as configured; this must happen after merging
+func (table *Table) Modify() error {
+
+	if table.commonDef == nil {
+		return errNotMerged
+	}
+
+	if errModify := table.commonDef.handleModifiedColumns(table.config, table.jsonMaps); errModify != nil {
+		return fmt.Errorf("error while handling the configured modified columns: %w", errModify)
+	}
+
+	return nil
+}
+
+// Insert : inserting the configured new columns; this must happen after merging
+func (table *Table) Insert() error {
+
+	if table.commonDef == nil {
+		return errNotMerged
+	}
+
+	if errInsert := table.commonDef.insertNewColumns(table.config); errInsert != nil {
+		return fmt.Errorf("error while inserting the configured new columns: %w", errInsert)
+	}
+
+	return nil
+}
+
+// UpdateConfig : adding to the config the columns that are not in there yet - i.e. all of them, the first time - along
+// with their color, width and detected stat; the columns already configured are left untouched, so as to keep the user's
+// changes. Returns true if the config has changed, and should be saved; this must happen after inserting
+func (table *Table) UpdateConfig() (bool, error) {
+
+	if table.commonDef == nil {
+		return false, errNotMerged
+	}
+
+	table.commonDef.reorder()
+	columns, errColumns := table.commonDef.getColumnConfigs()
+	if errColumns != nil {
+		return false, fmt.Errorf("error while listing the columns for the config: %w", errColumns)
+	}
+
+	added := table.config.addColumns(columns)
+
+	return added || !table.hasConfig, nil
+}
+
+// WriteConfig : writing out the config, as JSON, e.g. after updating it; the entries it does not know are kept as they are
+func (table *Table) WriteConfig(writer io.Writer) error {
+
+	if errWrite := writeJSON(writer, table.config); errWrite != nil {
+		return fmt.Errorf("error while writing the config: %w", errWrite)
+	}
+
+	return nil
+}
+
+// WriteSchema : writing out a JSON Schema (draft 2020-12) of the JSON documents, as merged: the properties found in all
+// the documents are required, and the ones detected as categories are enums; this must happen after merging
+func (table *Table) WriteSchema(writer io.Writer) error {
+
+	if table.commonDef == nil {
+		return errNotMerged
+	}
+
+	context := newSchemaContext(table.commonDef, table.arrays)
+	schema, errSchema := table.commonDef.getSchema(context, table.jsonMaps)
+	if errSchema != nil {
+		return fmt.Errorf("error while building the schema: %w", errSchema)
+	}
+	schema.Schema = schemaDraft
+	schema.Title = table.name
+
+	if errWrite := writeJSON(writer, schema); errWrite != nil {
+		return fmt.Errorf("error while writing the schema: %w", errWrite)
+	}
+
+	return nil
+}
+
+// Write : writing all the outputs, each output file being given by the opener
+func (table *Table) Write(open Opener) error {
+
+	if table.commonDef == nil {
+		return errNotMerged
+	}
+
+	outputs, errOutputs := table.config.getOutputs(table.options.Formats)
+	if errOutputs != nil {
+		return errOutputs
+	}
+	statsPlace, errPlace := table.config.getStatsPlace(table.options.Stats)
+	if errPlace != nil {
+		return errPlace
+	}
+	table.config.statsPlace = statsPlace
+	streamingThreshold, errThreshold := table.config.getStreamingThreshold(table.options.StreamingThreshold)
+	if errThreshold != nil {
+		return errThreshold
+	}
+	table.config.streamingThreshold = streamingThreshold
+	table.config.excelTable = table.config.getExcelTable(table.options.Table)
+
+	if errWrite := table.commonDef.writeOutputs(table.config, table.jsonMaps, outputs, open); errWrite != nil {
+		return fmt.Errorf("error while writing the outputs: %w", errWrite)
+	}
+
+	return nil
+}
+
+// Run : the whole pipeline, from the JSON documents of the given file system to the output files;
+// the config is read from the file system too, if present, and is created - or updated - with the opener
+func (table *Table) Run(fsys fs.FS, open Opener) error {
+
+	if errConf := table.loadConfigFS(fsys); errConf != nil {
+		return errConf
+	}
+	if errScan := table.ScanFS(fsys); errScan != nil {
+		return errScan
+	}
+
+	return table.process(open)
+}
+
+// RunReader : the whole pipeline, from a stream of JSON documents - e.g. the standard input - named after the table,
+// to the output files; the config is read from the given file system, if present, and is created - or updated - with the opener
+func (table *Table) RunReader(reader io.Reader, configFS fs.FS, open Opener) error {
+
+	if errConf := table.loadConfigFS(configFS); errConf != nil {
+		return errConf
+	}
+	if errScan := table.Scan(table.name, reader); errScan != nil {
+		return errScan
+	}
+
+	return table.process(open)
+}
+
+// reading the config file from the given file system, if present
+func (table *Table) loadConfigFS(fsys fs.FS) error {
+
+	configFile, errOpen := fsys.Open(table.ConfigFileName())
+	if errors.Is(errOpen, fs.ErrNotExist) {
+		return nil
+	}
+	if errOpen != nil {
+		return fmt.Errorf("error while opening the config file: %w", errOpen)
+	}
+	defer configFile.Close()
+
+	return table.LoadConfig(configFile)
+}
+
+// the steps of the pipeline following the scanning
+func (table *Table) process(open Opener) error {
+
+	if table.schema != nil {
+		if errValidate := table.Validate(); errValidate != nil {
+			return errValidate
+		}
+	}
+	if errMerge := table.Merge(); errMerge != nil {
+		return errMerge
+	}
+	if errModify := table.Modify(); errModify != nil {
+		return errModify
+	}
+	if errInsert := table.Insert(); errInsert != nil {
+		return errInsert
+	}
+	if errConfig := table.saveConfig(open); errConfig != nil {
+		return errConfig
+	}
+
+	return table.Write(open)
+}
+
+// creating the config file, or updating it with the new columns, if needed
+func (table *Table) saveConfig(open Opener) error {
+
+	changed, errUpdate := table.UpdateConfig()
+	if errUpdate != nil || !changed {
+		return errUpdate
+	}
+
+	writer, errOpen := open(".json")
+	if errOpen != nil {
+		return fmt.Errorf("error while creating the config file: %w", errOpen)
+	}
+	if errWrite := table.WriteConfig(writer); errWrite != nil {
+		writer.Close()
+		return errWrite
+	}
+
+	return writer.Close()
+}
+
+// Formats : the names of the available output formats
+func Formats() []string {
+	return availableOutputs()
+}
+
+// DefaultFormats : the names of the output formats written when none is asked for, nor configured
+func DefaultFormats() []string {
+	return append([]string{}, defaultOutputs...)
+}
+
+// DefaultIncludePatterns : the patterns of the files scanned when none is given, nor configured
+func DefaultIncludePatterns() []string {
+	return append([]string{}, defaultIncludePatterns...)
+}
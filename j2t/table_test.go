@@ -0,0 +1,165 @@
+//------------------------------------------------------------------------------
+// testing the public API, i.e. the whole pipeline, from JSON documents held in
+// memory to output files written in memory
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	excel "github.com/360EntSecGroup-Skylar/excelize"
+)
+
+// an output file written in memory
+type memoryFile struct {
+	bytes.Buffer
+}
+
+func (file *memoryFile) Close() error {
+	return nil
+}
+
+// an opener keeping all the output files in memory, by name suffix
+func memoryOpener(files map[string]*memoryFile) Opener {
+	return func(nameSuffix string) (io.WriteCloser, error) {
+		file := &memoryFile{}
+		files[nameSuffix] = file
+		return file, nil
+	}
+}
+
+// a few JSON documents, with a section, an array exploded into a child sheet, and a computed column
+var sampleFS = fstest.MapFS{
+	"a.json": {Data: []byte(`{"id": 1, "name": "alpha", "price": 10.5, "address": {"city": "Paris", "zip": "75001"}, "items": [{"sku": "A1", "qty": 2}]}`)},
+	"b.json": {Data: []byte(`{"id": 2, "name": "beta", "price": 3, "address": {"city": "Lyon", "zip": "69001"}, "items": []}`)},
+	"c.json": {Data: []byte(`{"id": 3, "name": null, "price": 7.25, "address": {"city": "Paris"}, "items": [{"sku": "C3", "qty": 5}, {"sku": "A1"}]}`)},
+	"sample.json": {Data: []byte(`{
+		"NewColumns": [{"Name": "total", "PutAfter": "price", "Formula": "{price}*{id}+1"}],
+		"Arrays": [{"Path": "items", "Strategy": "sheet"}]
+	}`)},
+}
+
+// running the whole pipeline over the sample documents
+func runSample(options Options) (map[string]*memoryFile, error) {
+	files := map[string]*memoryFile{}
+	table := NewTable("sample", options)
+	return files, table.Run(sampleFS, memoryOpener(files))
+}
+
+// 2 tables, with different options, running at the same time, should not share any state, and should give the same
+// outputs as when run one after the other - which "go test -race" checks too
+func TestConcurrentTables(t *testing.T) {
+
+	optionSets := []Options{
+		{Stats: "below"},
+		{Stats: "sheet", Table: true},
+	}
+
+	// the expected outputs, when the tables run 1 at a time
+	expected := make([]map[string]*memoryFile, len(optionSets))
+	for i, options := range optionSets {
+		files, errRun := runSample(options)
+		if errRun != nil {
+			t.Fatalf("could not run table %d: %v", i, errRun)
+		}
+		expected[i] = files
+	}
+
+	// the outputs, when the tables run at the same time
+	got := make([]map[string]*memoryFile, len(optionSets))
+	errs := make([]error, len(optionSets))
+	var waitGroup sync.WaitGroup
+	for i, options := range optionSets {
+		waitGroup.Add(1)
+		go func(i int, options Options) {
+			defer waitGroup.Done()
+			got[i], errs[i] = runSample(options)
+		}(i, options)
+	}
+	waitGroup.Wait()
+
+	for i := range optionSets {
+		if errs[i] != nil {
+			t.Fatalf("could not run table %d concurrently: %v", i, errs[i])
+		}
+		for _, suffix := range []string{".csv", ".items.csv", ".json"} {
+			if got[i][suffix] == nil {
+				t.Fatalf("table %d did not write its '%s' file", i, suffix)
+			}
+			if got[i][suffix].String() != expected[i][suffix].String() {
+				t.Errorf("table %d wrote a different '%s' file when run concurrently:\n%s\ninstead of:\n%s",
+					i, suffix, got[i][suffix], expected[i][suffix])
+			}
+		}
+		if got[i][".xlsx"] == nil || got[i][".xlsx"].Len() == 0 {
+			t.Errorf("table %d did not write its Excel file", i)
+		}
+	}
+}
+
+// the values - or formulae - of all the cells of the given sheet, up to the given row & column
+func getSheetContent(t *testing.T, file *memoryFile, sheet string, lastRow, lastCol int) map[string]string {
+
+	excelFile, errOpen := excel.OpenReader(bytes.NewReader(file.Bytes()))
+	if errOpen != nil {
+		t.Fatalf("could not read the Excel file: %v", errOpen)
+	}
+
+	content := map[string]string{}
+	for row := 1; row <= lastRow; row++ {
+		for col := 1; col <= lastCol; col++ {
+			cell, _ := getCell(row, col)
+			value, errValue := excelFile.GetCellFormula(sheet, cell)
+			if errValue == nil && value == "" {
+				value, errValue = excelFile.GetCellValue(sheet, cell)
+			}
+			if errValue != nil {
+				t.Fatalf("could not read cell %s of sheet '%s': %v", cell, sheet, errValue)
+			}
+			if value != "" {
+				content[cell] = value
+			}
+		}
+	}
+
+	return content
+}
+
+// a streamed sheet should hold the same headers, formulae & stats as a sheet built in memory
+func TestStreamedSheets(t *testing.T) {
+
+	for _, options := range []Options{{Stats: "below"}, {Stats: "below", Table: true}} {
+
+		inMemory, errMemory := runSample(options)
+		if errMemory != nil {
+			t.Fatalf("could not run the table in memory: %v", errMemory)
+		}
+		options.StreamingThreshold = 1
+		streamed, errStreamed := runSample(options)
+		if errStreamed != nil {
+			t.Fatalf("could not run the table with streamed sheets: %v", errStreamed)
+		}
+
+		for _, sheet := range []string{mainSheetName, "items"} {
+			expected := getSheetContent(t, inMemory[".xlsx"], sheet, 30, 12)
+			got := getSheetContent(t, streamed[".xlsx"], sheet, 30, 12)
+			if len(got) != len(expected) {
+				t.Errorf("table: %t; sheet '%s' has %d cells when streamed, instead of %d", options.Table, sheet, len(got), len(expected))
+			}
+			for cell, value := range expected {
+				if got[cell] != value {
+					t.Errorf("table: %t; cell %s of sheet '%s' is '%s' when streamed, instead of '%s'", options.Table, cell, sheet, got[cell], value)
+				}
+			}
+		}
+	}
+
+	if _, errRun := runSample(Options{StreamingThreshold: -1}); errRun == nil {
+		t.Errorf("expected an error for a negative streaming threshold")
+	}
+}
@@ -0,0 +1,344 @@
+//------------------------------------------------------------------------------
+// Utilities
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	excel "github.com/360EntSecGroup-Skylar/excelize"
+)
+
+//------------------------------------------------------------------------------
+// Excel file access
+//------------------------------------------------------------------------------
+
+// getting the cell for the given row and col
+func getCell(row int, col int) (string, error) {
+	coord, errCoord := excel.CoordinatesToCellName(col, row)
+	if errCoord != nil {
+		return "", fmt.Errorf("could not get coordinates at row '%d' and column '%d'. Cause: %w", row, col, errCoord)
+	}
+	return coord, nil
+}
+
+// getting a string value from the given sheet
+func getString(excelFile *workbook, sheet string, row int, col int) (string, error) {
+	coord, errCoord := getCell(row, col)
+	if errCoord != nil {
+		return "", errCoord
+	}
+	value, errGet := excelFile.GetCellValue(sheet, coord)
+	if errGet != nil {
+		return "", fmt.Errorf("error while getting at cell %s. Cause: %w", coord, errGet)
+	}
+	return value, nil
+}
+
+// setting a bool value into the given sheet
+func setBool(excelFile *workbook, sheet string, row int, col int, value bool) error {
+	return setString(excelFile, sheet, row, col, yesNo(value))
+}
+
+// how a boolean value is written out
+func yesNo(value bool) string {
+	if value {
+		return "YES"
+	}
+	return "NO"
+}
+
+// setting a string value into the given sheet
+func setString(excelFile *workbook, sheet string, row int, col int, value string) error {
+	coord, errCoord := getCell(row, col)
+	if errCoord != nil {
+		return errCoord
+	}
+	if errSet := excelFile.SetCellStr(sheet, coord, value); errSet != nil {
+		return fmt.Errorf("error while setting value '%v' at cell %s. Cause: %w", value, coord, errSet)
+	}
+	return nil
+}
+
+// setting a float value into the given sheet
+func setFloat(excelFile *workbook, sheet string, row int, col int, value float64) error {
+	coord, errCoord := getCell(row, col)
+	if errCoord != nil {
+		return errCoord
+	}
+	if errSet := excelFile.SetCellFloat(sheet, coord, value, -1, 64); errSet != nil {
+		return fmt.Errorf("error while setting value '%v' at cell %s. Cause: %w", value, coord, errSet)
+	}
+	return nil
+}
+
+// setting a JSON number into the given sheet: as is if Excel can hold it, as text otherwise
+func setNumber(excelFile *workbook, sheet string, row int, col int, value json.Number) error {
+	if !excelCanHold(value) {
+		return setString(excelFile, sheet, row, col, value.String())
+	}
+	coord, errCoord := getCell(row, col)
+	if errCoord != nil {
+		return errCoord
+	}
+	if errSet := excelFile.SetCellDefault(sheet, coord, value.String()); errSet != nil {
+		return fmt.Errorf("error while setting value '%v' at cell %s. Cause: %w", value, coord, errSet)
+	}
+	return nil
+}
+
+// setting a formula into the given sheet
+func setFormula(excelFile *workbook, sheet string, row int, col int, formula string) error {
+	coord, errCoord := getCell(row, col)
+	if errCoord != nil {
+		return errCoord
+	}
+	if errSet := excelFile.SetCellFormula(sheet, coord, escapeFormula(formula)); errSet != nil {
+		return fmt.Errorf("error while setting formula '%s' at cell %s. Cause: %w", formula, coord, errSet)
+	}
+	return nil
+}
+
+// the given formula, as excelize has to be given it: a cell's formula is written into the sheet's XML as is, so its
+// '&', '<', '>' & quotes have to be escaped
+func escapeFormula(formula string) string {
+	escaped := &strings.Builder{}
+	xml.EscapeText(escaped, []byte(formula))
+	return escaped.String()
+}
+
+// setting a style onto 1 cell of the given sheet
+func setStyle(excelFile *workbook, sheet string, row int, col int, style int) error {
+	coord, errCoord := getCell(row, col)
+	if errCoord != nil {
+		return errCoord
+	}
+	if errSet := excelFile.SetCellStyle(sheet, coord, coord, style); errSet != nil {
+		return fmt.Errorf("error while setting a style at cell %s. Cause: %w", coord, errSet)
+	}
+	return nil
+}
+
+// merging cells from the given rows and cols, within the given sheet
+func mergeCells(excelFile *workbook, sheet string, fromRow, fromCol, toRow, toCol int) error {
+	fromCoord, errFrom := getCell(fromRow, fromCol)
+	if errFrom != nil {
+		return errFrom
+	}
+	toCoord, errTo := getCell(toRow, toCol)
+	if errTo != nil {
+		return errTo
+	}
+	if errMerge := excelFile.MergeCell(sheet, fromCoord, toCoord); errMerge != nil {
+		return fmt.Errorf("error while merging cells %s:%s. Cause: %w", fromCoord, toCoord, errMerge)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+// Dealing with colors
+//------------------------------------------------------------------------------
+
+var black = "#000000"
+var white = "#FFFFFF"
+
+var colors = []string{
+	"#1A5276",
+	"#0E6655",
+	"#9A7D0A",
+	"#873600",
+	"#7B241C",
+	"#5B2C6F",
+	"#283747",
+	"#196F3D",
+}
+
+// the adjusted colors computed so far, shared by all the tables - which can be written at the same time
+var colorChart = map[string]string{}
+var colorChartLock sync.Mutex
+
+// lightening or darkening a color
+func getAdjustedColor(color string, addedLight int, forceLight bool) string {
+
+	colorKey := fmt.Sprintf("%s/%d", color, addedLight)
+
+	colorChartLock.Lock()
+	lightenedColor := colorChart[colorKey]
+	colorChartLock.Unlock()
+	if lightenedColor != "" {
+		return lightenedColor
+	}
+
+	usePound := false
+
+	if color[0] == '#' {
+		color = color[1:len(color)]
+		usePound = true
+	}
+
+	R, _ := strconv.ParseInt(color[0:2], 16, 8)
+	G, _ := strconv.ParseInt(color[2:4], 16, 8)
+	B, _ := strconv.ParseInt(color[4:6], 16, 8)
+
+	R = R + int64(addedLight)
+	G = G + int64(addedLight)
+	B = B + int64(addedLight)
+
+	// going darker if we're too light here
+	if R+G+B > 3*210 && !forceLight {
+		return getAdjustedColor(color, -15, forceLight)
+	}
+
+	if R > 255 {
+		R = 255
+	} else if R < 0 {
+		R = 0
+	}
+
+	if G > 255 {
+		G = 255
+	} else if G < 0 {
+		G = 0
+	}
+
+	if B > 255 {
+		B = 255
+	} else if B < 0 {
+		B = 0
+	}
+
+	RR := fmt.Sprintf("%02s", fmt.Sprintf("%x", R))
+	GG := fmt.Sprintf("%02s", fmt.Sprintf("%x", G))
+	BB := fmt.Sprintf("%02s", fmt.Sprintf("%x", B))
+
+	result := RR + GG + BB
+	if usePound {
+		result = "#" + result
+	}
+
+	// "caching" for later faster retrieval
+	colorChartLock.Lock()
+	colorChart[colorKey] = result
+	colorChartLock.Unlock()
+
+	return result
+}
+
+//------------------------------------------------------------------------------
+// Numbers
+//------------------------------------------------------------------------------
+
+// Excel only keeps 15 significant digits
+const excelPrecision = 15
+
+// the kind of a JSON value; the numbers, kept as json.Number, are of the reflect.Float64 kind
+func valueKind(value interface{}) reflect.Kind {
+	if _, isNumber := value.(json.Number); isNumber {
+		return reflect.Float64
+	}
+	return reflect.ValueOf(value).Kind()
+}
+
+// can Excel hold this JSON number without losing any digit ?
+func excelCanHold(number json.Number) bool {
+
+	// counting the significant digits, i.e. the ones of the mantissa, without the leading and trailing zeros
+	mantissa := strings.ToLower(strings.TrimLeft(number.String(), "-"))
+	if exponentIndex := strings.Index(mantissa, "e"); exponentIndex >= 0 {
+		mantissa = mantissa[:exponentIndex]
+	}
+	digits := strings.Trim(strings.Replace(mantissa, ".", "", 1), "0")
+	if len(digits) > excelPrecision {
+		return false
+	}
+
+	// the number must be in Excel's range - a tiny number being parsed as 0
+	value, errParse := strconv.ParseFloat(number.String(), 64)
+	return errParse == nil && (value == 0) == (digits == "") && (value == 0 || math.Abs(value) >= 1e-307)
+}
+
+// does this number literal have decimals ? e.g. "0.1" does, "1.0" or "1e3" do not
+func isDecimal(literal string) bool {
+	value, isNumber := new(big.Rat).SetString(literal)
+	return isNumber && !value.IsInt()
+}
+
+//------------------------------------------------------------------------------
+// JSON
+//------------------------------------------------------------------------------
+
+// writing the given value as indented JSON, with no HTML escaping, e.g. "<null>" being kept as is
+func writeJSON(writer io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// reading the given JSON object into the given struct, and returning its members the struct does not know
+func unmarshalKeepingUnknown(jsonBytes []byte, known interface{}) (map[string]json.RawMessage, error) {
+
+	if errUnmarshal := json.Unmarshal(jsonBytes, known); errUnmarshal != nil {
+		return nil, errUnmarshal
+	}
+
+	members := map[string]json.RawMessage{}
+	if errUnmarshal := json.Unmarshal(jsonBytes, &members); errUnmarshal != nil {
+		return nil, errUnmarshal
+	}
+	knownType := reflect.TypeOf(known).Elem()
+	for i := 0; i < knownType.NumField(); i++ {
+		if name := strings.Split(knownType.Field(i).Tag.Get("json"), ",")[0]; name != "" {
+			delete(members, name)
+		}
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	return members, nil
+}
+
+// writing the given struct as a JSON object, followed by the given unknown members, in alphabetical order
+func marshalWithUnknown(known interface{}, unknown map[string]json.RawMessage) ([]byte, error) {
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if errEncode := encoder.Encode(known); errEncode != nil {
+		return nil, errEncode
+	}
+	if len(unknown) == 0 {
+		return buffer.Bytes(), nil
+	}
+
+	names := []string{}
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// the known members, without the closing brace
+	object := bytes.TrimSuffix(bytes.TrimSpace(buffer.Bytes()), []byte("}"))
+	for i, name := range names {
+		if i > 0 || len(object) > 1 {
+			object = append(object, ',')
+		}
+		nameBytes, _ := json.Marshal(name)
+		object = append(append(append(object, nameBytes...), ':'), unknown[name]...)
+	}
+
+	return append(object, '}'), nil
+}
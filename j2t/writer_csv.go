@@ -0,0 +1,245 @@
+//------------------------------------------------------------------------------
+// the code here is responsible for writing the CSV file
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	csvQuotingMINIMAL = "minimal" // quoting only the values that need it
+	csvQuotingALL     = "all"     // quoting all the values
+	csvBOM            = "\uFEFF"  // the UTF-8 byte order mark, which helps Excel with the encoding
+)
+
+// the CSV output
+type csvWriter struct{}
+
+func init() {
+	registerOutput("csv", csvWriter{})
+}
+
+func (writer csvWriter) write(conf *j2tConfig, commonDef *fileMap, jsonMaps []*fileMap, open Opener) error {
+	return commonDef.writeCSV(conf, jsonMaps, open)
+}
+
+// writing the CSV file, and 1 more CSV file per child sheet
+func (commonDef *fileMap) writeCSV(conf *j2tConfig, jsonMaps []*fileMap, open Opener) error {
+
+	if errWrite := commonDef.writeCSVFile(conf, jsonMaps, open, ".csv"); errWrite != nil {
+		return errWrite
+	}
+
+	for _, child := range commonDef.childSheets {
+		if errWrite := child.commonDef.writeCSVFile(conf, child.jsonMaps, open, "."+child.commonDef.sheet()+".csv"); errWrite != nil {
+			return errWrite
+		}
+	}
+
+	return nil
+}
+
+// writing 1 CSV file for this common definition
+func (commonDef *fileMap) writeCSVFile(conf *j2tConfig, jsonMaps []*fileMap, open Opener, nameSuffix string) error {
+
+	writer, errOpen := open(nameSuffix)
+	if errOpen != nil {
+		return &WriteError{Err: fmt.Errorf("could not create the '%s' file: %w", nameSuffix, errOpen)}
+	}
+	if errWrite := commonDef.writeCSVContent(writer, conf, jsonMaps); errWrite != nil {
+		writer.Close()
+		return fmt.Errorf("could not write the '%s' file: %w", nameSuffix, errWrite)
+	}
+
+	// we're done
+	return writer.Close()
+}
+
+// writing the CSV content: 1 header line with the properties' paths, then 1 line per JSON map
+func (commonDef *fileMap) writeCSVContent(writer io.Writer, conf *j2tConfig, jsonMaps []*fileMap) error {
+
+	csvConf, errConf := conf.getCSVConfig()
+	if errConf != nil {
+		return errConf
+	}
+
+	// following the same order as for the Excel file
+	commonDef.reorder()
+	currentIndex := 1
+	commonDef.index(&currentIndex)
+	columns := []*chainedProperty{}
+	for _, column := range commonDef.getColumns() {
+		if !column.conf.hidden {
+			columns = append(columns, column)
+		}
+	}
+
+	buffer := bufio.NewWriter(writer)
+
+	if csvConf.BOM {
+		buffer.WriteString(csvBOM)
+	}
+
+	// the headers
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.getFlatHeader()
+	}
+	csvConf.writeRecord(buffer, headers)
+
+	// the lines
+	for _, jsonMap := range jsonMaps {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			value, errValue := commonDef.getCSVValue(conf, jsonMap, column)
+			if errValue != nil {
+				return &WriteError{Path: string(column.getPath()), File: jsonMap.name, Err: errValue}
+			}
+			record[i] = value
+		}
+		csvConf.writeRecord(buffer, record)
+	}
+
+	return buffer.Flush()
+}
+
+// the final properties of this definition, i.e. the columns, in the right order
+func (commonDef *fileMap) getColumns() (columns []*chainedProperty) {
+	for _, property := range commonDef.orderedProperties {
+		if subMap := commonDef.subMaps[property]; subMap != nil {
+			columns = append(columns, subMap.getColumns()...)
+		} else {
+			columns = append(columns, commonDef.chainedProperties[property])
+		}
+	}
+	return
+}
+
+// the value of a given column for the given JSON map, as a CSV string
+func (commonDef *fileMap) getCSVValue(conf *j2tConfig, jsonMap *fileMap, column *chainedProperty) (string, error) {
+
+	// a computed value
+	if column.computed {
+		value, errCompute := commonDef.computeValue(jsonMap, column)
+		if formulaErr, isFormulaError := errCompute.(formulaError); isFormulaError {
+			return formulaErr.Error(), nil
+		}
+		if errCompute != nil {
+			return "", errCompute
+		}
+		return formulaString(value), nil
+	}
+
+	// the JSON map does not have this property
+	jsonProp := jsonMap.findProp(column.getPath())
+	if jsonProp == nil {
+		return "", nil
+	}
+
+	value := jsonProp.owner.values[jsonProp.name]
+	if value == nil {
+		return conf.nullValue(), nil
+	}
+
+	switch typedValue := value.(type) {
+	case bool:
+		if column.kind == reflect.Bool {
+			if typedValue {
+				return "YES", nil
+			}
+			return "NO", nil
+		}
+	case string:
+		if column.kind == reflect.String {
+			return typedValue, nil
+		}
+	case float64:
+		if column.kind == reflect.Float64 {
+			if typedValue != -999999 {
+				return strconv.FormatFloat(typedValue, 'f', -1, 64), nil
+			}
+			return "", nil
+		}
+	case json.Number:
+		if column.kind == reflect.Float64 {
+			if typedValue != "-999999" {
+				return typedValue.String(), nil
+			}
+			return "", nil
+		}
+	}
+
+	return "", column.conflictWith(jsonMap, value)
+}
+
+// evaluating a computed property for the given JSON map, since a CSV file cannot hold formulae
+func (commonDef *fileMap) computeValue(jsonMap *fileMap, computedProp *chainedProperty) (interface{}, error) {
+
+	return computedProp.computationDef.expression.eval(func(propPath path) (interface{}, error) {
+
+		// a property that's computed too
+		if prop := commonDef.getProp(propPath); prop != nil && prop.computed {
+			return commonDef.computeValue(jsonMap, prop)
+		}
+
+		// a property from the JSON map, if it has it; the formulae work with float64 numbers
+		if jsonProp := jsonMap.findProp(propPath); jsonProp != nil {
+			if number, isNumber := jsonProp.owner.values[jsonProp.name].(json.Number); isNumber {
+				return number.Float64()
+			}
+			return jsonProp.owner.values[jsonProp.name], nil
+		}
+
+		return nil, nil
+	})
+}
+
+// the CSV config, with its default values
+func (config *j2tConfig) getCSVConfig() (*csvConfig, error) {
+
+	csvConf := &csvConfig{Delimiter: ",", Quoting: csvQuotingMINIMAL}
+
+	if config.CSV != nil {
+		if config.CSV.Delimiter != "" {
+			csvConf.Delimiter = config.CSV.Delimiter
+		}
+		if config.CSV.Quoting != "" {
+			csvConf.Quoting = config.CSV.Quoting
+		}
+		csvConf.BOM = config.CSV.BOM
+	}
+
+	if utf8.RuneCountInString(csvConf.Delimiter) != 1 || strings.ContainsAny(csvConf.Delimiter, "\"\r\n") {
+		return nil, fmt.Errorf("invalid CSV delimiter: '%s'", csvConf.Delimiter)
+	}
+	if csvConf.Quoting != csvQuotingMINIMAL && csvConf.Quoting != csvQuotingALL {
+		return nil, fmt.Errorf("invalid CSV quoting: '%s'; possible values are: '%s', '%s'", csvConf.Quoting, csvQuotingMINIMAL, csvQuotingALL)
+	}
+
+	return csvConf, nil
+}
+
+// writing 1 CSV line
+func (csvConf *csvConfig) writeRecord(buffer *bufio.Writer, record []string) {
+	for i, value := range record {
+		if i > 0 {
+			buffer.WriteString(csvConf.Delimiter)
+		}
+		if csvConf.Quoting == csvQuotingALL || strings.ContainsAny(value, csvConf.Delimiter+"\"\r\n") ||
+			strings.HasPrefix(value, " ") || strings.HasSuffix(value, " ") {
+			buffer.WriteString("\"" + strings.Replace(value, "\"", "\"\"", -1) + "\"")
+		} else {
+			buffer.WriteString(value)
+		}
+	}
+	buffer.WriteString("\n")
+}
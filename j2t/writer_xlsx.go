@@ -0,0 +1,16 @@
+//------------------------------------------------------------------------------
+// the code here registers the XLSX output, which writes the Excel file
+//------------------------------------------------------------------------------
+
+package j2t
+
+// the XLSX output
+type xlsxWriter struct{}
+
+func init() {
+	registerOutput("xlsx", xlsxWriter{})
+}
+
+func (writer xlsxWriter) write(conf *j2tConfig, commonDef *fileMap, jsonMaps []*fileMap, open Opener) error {
+	return commonDef.writeExcel(conf, jsonMaps, open)
+}
@@ -0,0 +1,90 @@
+//------------------------------------------------------------------------------
+// the registry of the outputs we can produce from the common definition
+// and the JSON maps, e.g. "xlsx" or "csv"
+//------------------------------------------------------------------------------
+
+package j2t
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// the outputs written by default, when none is configured
+var defaultOutputs = []string{"xlsx", "csv"}
+
+// what all the outputs have to implement
+type outputWriter interface {
+	write(conf *j2tConfig, commonDef *fileMap, jsonMaps []*fileMap, open Opener) error
+}
+
+// Opener : gives the writer for an output file, given the end of its name, e.g. ".xlsx", or ".items.csv";
+// an output can open several files, e.g. 1 CSV file per child sheet
+type Opener func(nameSuffix string) (io.WriteCloser, error)
+
+// DirOpener : an opener creating the output files within the given directory, their names starting with the given name
+func DirOpener(dir string, name string) Opener {
+	return func(nameSuffix string) (io.WriteCloser, error) {
+		return os.Create(filepath.Join(dir, name+nameSuffix))
+	}
+}
+
+// the available outputs, by format name
+var outputWriters = map[string]outputWriter{}
+
+// making an output available; this is meant to be called from an init function
+func registerOutput(format string, writer outputWriter) {
+	outputWriters[format] = writer
+}
+
+// the names of the available outputs, sorted
+func availableOutputs() []string {
+	formats := []string{}
+	for format := range outputWriters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// the outputs to write: the ones asked for on the command line, or else the configured ones, or else the default ones
+func (config *j2tConfig) getOutputs(askedFormats []string) ([]outputWriter, error) {
+
+	formats := askedFormats
+	if len(formats) == 0 && config.General != nil {
+		formats = config.General.Outputs
+	}
+	if len(formats) == 0 {
+		formats = defaultOutputs
+	}
+
+	writers := []outputWriter{}
+	alreadyAsked := map[string]bool{}
+	for _, format := range formats {
+		format = strings.ToLower(strings.TrimSpace(format))
+		writer := outputWriters[format]
+		if writer == nil {
+			return nil, fmt.Errorf("unknown output format '%s'; available formats: %s", format, strings.Join(availableOutputs(), ", "))
+		}
+		if !alreadyAsked[format] {
+			writers = append(writers, writer)
+			alreadyAsked[format] = true
+		}
+	}
+
+	return writers, nil
+}
+
+// writing all the asked outputs
+func (commonDef *fileMap) writeOutputs(conf *j2tConfig, jsonMaps []*fileMap, writers []outputWriter, open Opener) error {
+	for _, writer := range writers {
+		if errWrite := writer.write(conf, commonDef, jsonMaps, open); errWrite != nil {
+			return errWrite
+		}
+	}
+	return nil
+}
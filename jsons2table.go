@@ -3,22 +3,34 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"runtime/debug"
-	"sort"
+	"path/filepath"
+	"strings"
 
+	"github.com/ninjawule/jsons2table/j2t"
 	_ "github.com/tealeg/xlsx"
 )
 
-var debugMode bool
-var continueMode bool
+// the values given on the command line, e.g. the output formats, either with a repeated flag, or with comma-separated values
+type listFlag []string
 
-func log(strfmt string, params ...interface{}) {
-	if debugMode {
-		println(fmt.Sprintf(strfmt, params...))
+func (values *listFlag) String() string {
+	return strings.Join(*values, ",")
+}
+
+func (values *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*values = append(*values, item)
+		}
 	}
+	return nil
 }
 
+// the name of the table when reading the standard input
+const stdinName = "stdin"
+
 func main() {
 
 	// a bit of doc
@@ -27,14 +39,40 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\navailable flags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\narguments:\n")
-		fmt.Fprintf(os.Stderr, "  folder_path: mandatory - the path to the folder containing the JSON files\n")
+		fmt.Fprintf(os.Stderr, "  folder_path: mandatory - the path to the folder containing the JSON files;"+
+			" or '-' to read JSON documents from the standard input, the outputs being written into the current folder\n")
 		fmt.Fprintf(os.Stderr, "\n")
 	}
 
 	// adding the flags
-	flag.BoolVar(&debugMode, "debug", false, "runs the program in debug mode, i.e. with debug messages")
-	flag.BoolVar(&continueMode, "continue", false, "runs the program without stopping at the merging step")
+	options := j2t.Options{}
+	var outputFormats, includePatterns, excludePatterns listFlag
+	flag.BoolVar(&options.Debug, "debug", false, "runs the program in debug mode, i.e. with debug messages")
+	flag.BoolVar(&options.Continue, "continue", false, "runs the program without stopping at the type conflicts with no configured policy")
+	flag.Var(&outputFormats, "format", fmt.Sprintf("the output format(s), among: %s; can be repeated, or comma-separated (default: %s)",
+		strings.Join(j2t.Formats(), ", "), strings.Join(j2t.DefaultFormats(), ",")))
+	flag.Var(&includePatterns, "include", fmt.Sprintf("the pattern(s) of the files to scan, relative to the folder, where '**' matches any sub-folders,"+
+		" e.g. '**/*.json'; can be repeated, or comma-separated (default: %s)", strings.Join(j2t.DefaultIncludePatterns(), ",")))
+	flag.Var(&excludePatterns, "exclude", "the pattern(s) of the files, or sub-folders, not to scan, e.g. 'archive'; can be repeated, or comma-separated")
+	flag.StringVar(&options.RowsPath, "rows", "", "the path of the array of objects giving the rows within each JSON document, e.g. 'data/results';"+
+		" by default, each document gives 1 row, or 1 row per object if it's an array")
+	flag.IntVar(&options.Jobs, "jobs", 0, "how many files can be parsed at the same time (default: the number of CPUs)")
+	flag.StringVar(&options.Order, "order", "", "when the properties are not in the same order in all the files: 'strict' stops at the first conflict,"+
+		" 'vote' picks the order of the majority, and lists the conflicts (default: strict)")
+	validationPath := flag.String("validate", "", "the path of a JSON Schema to validate the JSON documents against, e.g. 'contract.json';"+
+		" the documents are marked as valid or not, in extra columns, and the schema gives the columns' order & types")
+	flag.BoolVar(&options.Table, "table", false, "writes the sheets of the Excel file as tables, with 1 header row giving the columns' paths,"+
+		" filters & banded rows, rather than the merged headers of the sections")
+	flag.StringVar(&options.Stats, "stats", "", "where to write the stats in the Excel file: 'below' the data of each sheet,"+
+		" or on a dedicated 'sheet', so that the data sheets can be sorted & filtered (default: below)")
+	flag.IntVar(&options.StreamingThreshold, "streaming-threshold", 0, "above this number of rows, a sheet of the Excel file is streamed,"+
+		" 1 row at a time, rather than built in memory (default: 10000)")
+	flag.BoolVar(&options.ExcludeInvalid, "exclude-invalid", false, "leaves out the documents that are not valid against the schema given with -validate")
+	schemaPath := flag.String("schema", "", "the path of a JSON file where to write the JSON Schema (draft 2020-12) of the JSON documents, e.g. 'schema.json'")
 	flag.Parse()
+	options.Formats = outputFormats
+	options.Include = includePatterns
+	options.Exclude = excludePatterns
 
 	// controlling the args
 	if flag.NArg() == 0 {
@@ -43,56 +81,109 @@ func main() {
 		os.Exit(1)
 	}
 	if flag.NArg() > 1 {
-		err("too many arguments, we only need the folder path here!")
+		fail("too many arguments, we only need the folder path here!")
+	}
+
+	// reading from the standard input, the config & output files being in the current folder
+	if flag.Arg(0) == "-" {
+		table := j2t.NewTable(stdinName, options)
+		loadSchema(table, *validationPath)
+		errRun := table.RunReader(os.Stdin, os.DirFS("."), createdFileOpener(".", stdinName))
+		printReport(table)
+		if errRun != nil {
+			fail("%s", errRun)
+		}
+		writeSchema(table, *schemaPath)
+		return
 	}
 
 	// getting the folder path, which should be a valid directory
 	folderPath := flag.Arg(0)
 	folderInfo, errPath := os.Stat(folderPath)
-	if os.IsNotExist(errPath) {
-		err("'%s' is not a valid directory!", folderPath)
+	if errPath != nil || !folderInfo.IsDir() {
+		fail("'%s' is not a valid directory!", folderPath)
+	}
+
+	// the table is named after the folder, and so are the config & output files
+	name := folderInfo.Name()
+	if absPath, errAbs := filepath.Abs(folderPath); errAbs == nil {
+		name = filepath.Base(absPath)
+	}
+
+	// running the whole pipeline
+	table := j2t.NewTable(name, options)
+	loadSchema(table, *validationPath)
+	errRun := table.Run(os.DirFS(folderPath), createdFileOpener(folderPath, name))
+	printReport(table)
+	if errRun != nil {
+		fail("%s", errRun)
+	}
+	writeSchema(table, *schemaPath)
+}
+
+// telling the user about what's been found along the way, e.g. the resolved type conflicts
+func printReport(table *j2t.Table) {
+	for _, line := range table.Report() {
+		println(line)
 	}
+}
 
-	// the name of the config file
-	configFileName := folderInfo.Name() + ".json"
+// reading the JSON Schema to validate the JSON documents against, if any
+func loadSchema(table *j2t.Table, validationPath string) {
+
+	if validationPath == "" {
+		return
+	}
 
-	// scanning all the files within the JSON folder
-	jsonMaps, errScan := scanDir(folderPath, configFileName)
-	if errScan != nil {
-		err("error while scanning: %s", errScan)
+	schemaFile, errOpen := os.Open(validationPath)
+	if errOpen != nil {
+		fail("error while opening the schema file: %s", errOpen)
 	}
 
-	// a bit of sorting, to make sure the treatment is always the same
-	sort.Slice(jsonMaps, func(i int, j int) bool {
-		return jsonMaps[i].name < jsonMaps[j].name
-	})
+	// closing the file before failing, if we have to, since exiting skips the deferred calls
+	errLoad := table.LoadSchema(schemaFile)
+	schemaFile.Close()
+	if errLoad != nil {
+		fail("%s", errLoad)
+	}
+}
 
-	// merging all the maps to determine the common definition
-	commonDef := merge(jsonMaps)
+// writing the JSON Schema of the JSON documents, if asked to
+func writeSchema(table *j2t.Table, schemaPath string) {
 
-	// retrieving or initialising the config
-	config, errConf := commonDef.getOrInitConfig(folderPath, folderInfo, configFileName)
-	if errConf != nil {
-		err("error while reading the config file: %s", errConf)
+	if schemaPath == "" {
+		return
 	}
 
-	// changing some columns, as configured
-	if errModify := commonDef.handleModifiedColumns(config, jsonMaps); errModify != nil {
-		err("error while handling the configured modified columns: %s", errModify)
+	schemaFile, errCreate := os.Create(schemaPath)
+	if errCreate != nil {
+		fail("error while creating the schema file: %s", errCreate)
 	}
 
-	// insert the configured new columns
-	if errInsert := commonDef.insertNewColumns(config); errInsert != nil {
-		err("error while inserting the configured new columns: %s", errInsert)
+	// closing the file can fail too, e.g. when the disk is full
+	if errWrite := table.WriteSchema(schemaFile); errWrite != nil {
+		schemaFile.Close()
+		fail("%s", errWrite)
+	}
+	if errClose := schemaFile.Close(); errClose != nil {
+		fail("error while writing the schema file: %s", errClose)
 	}
+	println(fmt.Sprintf("(re-)created file '%s'", schemaPath))
+}
 
-	// writing the Excel file
-	commonDef.writeExcel(config, jsonMaps)
+// creating the output files in the given folder, while telling the user about it
+func createdFileOpener(folderPath string, name string) j2t.Opener {
+	open := j2t.DirOpener(folderPath, name)
+	return func(nameSuffix string) (writeCloser io.WriteCloser, errOpen error) {
+		if writeCloser, errOpen = open(nameSuffix); errOpen == nil {
+			println(fmt.Sprintf("(re-)created file '%s'", filepath.Join(folderPath, name+nameSuffix)))
+		}
+		return
+	}
 }
 
 // fatal error handling
-func err(strfmt string, args ...interface{}) {
-	fmt.Printf(strfmt+"\n", args...)
-	debug.PrintStack()
+func fail(strfmt string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, strfmt+"\n", args...)
 	os.Exit(1)
 }
@@ -1,5 +0,0 @@
-//------------------------------------------------------------------------------
-// the code here is responsible for writing the CSV file
-//------------------------------------------------------------------------------
-
-package main
@@ -1,5 +0,0 @@
-//------------------------------------------------------------------------------
-// the code here is responsible for writing the XLSX file and the config file
-//------------------------------------------------------------------------------
-
-package main